@@ -2,100 +2,233 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"opentelemetry-api/internal/handlers"
+	"opentelemetry-api/internal/httpconv"
+	"opentelemetry-api/internal/httpserver"
+	"opentelemetry-api/internal/logging"
+	"opentelemetry-api/internal/metrics"
+	"opentelemetry-api/internal/otlpconfig"
+	"opentelemetry-api/internal/redact"
+	"opentelemetry-api/internal/resource"
+	"opentelemetry-api/internal/telemetry"
 	"opentelemetry-api/internal/tracing"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
-	"opentelemetry-api/internal/metrics"
 	m "opentelemetry-api/internal/middleware"
 )
 
 func main() {
-	// Create a Zap logger that writes to stdout
-	logger := zap.New(zapcore.NewCore(
+	// Create a Zap logger that writes to stdout, adding trace_id and span_id for entries logged with logging.Context
+	stdoutCore := logging.WithTraceContext(zapcore.NewCore(
 		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
 		zapcore.Lock(os.Stdout), // important: stdout for container logs
 		zapcore.InfoLevel,
-	), zap.AddCaller())
-	defer logger.Sync()
+	))
+	logger := zap.New(stdoutCore, zap.AddCaller())
+
+	// Redaction rules shared by log fields, span attributes and metric attributes
+	var redactor *redact.Redactor
+	if getEnvBool(logger, "REDACTION_ENABLED", true) {
+		redactor = loadRedactor(logger, getEnv("REDACTION_RULES_FILE", ""))
+		stdoutCore = redact.NewCore(stdoutCore, redactor)
+		logger = zap.New(stdoutCore, zap.AddCaller())
+	}
+
 	// Read configuration from environment variables
 	otelEndpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "otel-collector:4317")
 	serviceName := getEnv("SERVICE_NAME", "my-app")
 	requestCounterName := getEnv("REQUEST_COUNTER_NAME", "http_requests_total")
 	requestDurationName := getEnv("REQUEST_DURATION_NAME", "http_request_duration_seconds")
+	// "http" switches HTTP metrics and span attributes to the stable semantic conventions, "http/dup" emits both
+	httpSemconv := httpconv.ParseMode(getEnv("OTEL_SEMCONV_STABILITY_OPT_IN", ""))
 
-	// Initialize metrics and tracing
-	mp, err := metrics.InitMetrics(
-		otelEndpoint,
-		serviceName,
-		requestCounterName,
-		requestDurationName,
-		logger)
+	// Exporter connection settings shared by all signals
+	caFile := getEnv("OTEL_EXPORTER_OTLP_CERTIFICATE", "")
+	clientCertFile := getEnv("OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE", "")
+	otlpTLS := otlpconfig.TLSConfig{
+		// Keep plaintext as the default unless certificates are configured
+		Insecure:   getEnvBool(logger, "OTEL_EXPORTER_OTLP_INSECURE", caFile == "" && clientCertFile == ""),
+		CAFile:     caFile,
+		CertFile:   clientCertFile,
+		KeyFile:    getEnv("OTEL_EXPORTER_OTLP_CLIENT_KEY", ""),
+		ServerName: getEnv("OTEL_EXPORTER_OTLP_SERVER_NAME", ""),
+	}
+	otlpHeaders, err := otlpconfig.ParseHeaders(getEnv("OTEL_EXPORTER_OTLP_HEADERS", ""))
 	if err != nil {
-		logger.Fatal("Failed to initialize metrics", zap.Error(err))
+		logger.Fatal("Invalid OTLP headers", zap.Error(err))
 	}
-	defer func() {
-		if err := mp.Shutdown(context.Background()); err != nil {
-			logger.Error("failed to shutdown metric provider", zap.Error(err))
+	var otlpToken *otlpconfig.TokenFile
+	if tokenFile := getEnv("OTEL_EXPORTER_OTLP_TOKEN_FILE", ""); tokenFile != "" {
+		if otlpToken, err = otlpconfig.NewTokenFile(tokenFile); err != nil {
+			logger.Fatal("Failed to load OTLP token file", zap.Error(err))
 		}
-	}()
+		if otlpTLS.Insecure && getEnvBool(logger, "OTEL_EXPORTER_OTLP_TOKEN_ALLOW_INSECURE", false) {
+			logger.Warn("Sending the OTLP token over a plaintext connection", zap.String("token_file", tokenFile))
+			otlpToken.AllowInsecure()
+		}
+		if err := otlpToken.CheckTransport(otlpTLS); err != nil {
+			logger.Fatal("Invalid OTLP token configuration", zap.Error(err))
+		}
+	}
+	traceExporter, err := tracing.ParseExporterKind(
+		getEnv("OTEL_TRACES_EXPORTER", "otlp"),
+		getEnv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc"),
+	)
+	if err != nil {
+		logger.Fatal("Invalid trace exporter configuration", zap.Error(err))
+	}
+	traceSampler, err := tracing.NewSampler(
+		getEnv("OTEL_TRACES_SAMPLER", "parentbased_always_on"),
+		getEnv("OTEL_TRACES_SAMPLER_ARG", ""),
+		getEnv("OTEL_TRACES_SAMPLER_RULES_FILE", ""),
+	)
+	if err != nil {
+		logger.Fatal("Invalid trace sampler configuration", zap.Error(err))
+	}
+	propagator, err := tracing.NewPropagator(getEnv("OTEL_PROPAGATORS", "tracecontext,baggage"))
+	if err != nil {
+		logger.Fatal("Invalid propagator configuration", zap.Error(err))
+	}
+	tracingOpts := []tracing.Option{
+		tracing.WithExporter(traceExporter),
+		tracing.WithSampler(traceSampler),
+		tracing.WithPropagator(propagator),
+	}
+	if getEnvBool(logger, "TAIL_SAMPLING_ENABLED", false) {
+		tracingOpts = append(tracingOpts, tracing.WithTailSampling(tracing.TailSamplingConfig{
+			DecisionWait:     getEnvDuration(logger, "TAIL_SAMPLING_DECISION_WAIT", 10*time.Second),
+			LatencyThreshold: getEnvDuration(logger, "TAIL_SAMPLING_LATENCY_THRESHOLD", time.Second),
+			KeepRatio:        getEnvFloat(logger, "TAIL_SAMPLING_KEEP_RATIO", 0.1),
+			MaxTraces:        getEnvInt(logger, "TAIL_SAMPLING_MAX_TRACES", 10000),
+		}))
+	}
+
+	// Child span timings reported in the Server-Timing response header are collected by a span processor
+	serverTiming := getEnvBool(logger, "SERVER_TIMING_ENABLED", false)
+	var timingRecorder *m.ServerTimingRecorder
+	if serverTiming && getEnvBool(logger, "SERVER_TIMING_SPANS_ENABLED", false) {
+		timingRecorder = m.NewServerTimingRecorder()
+		tracingOpts = append(tracingOpts, tracing.WithSpanProcessor(timingRecorder))
+	}
 
-	tp, err := tracing.InitTracer(otelEndpoint, serviceName)
+	metricsExporters, err := metrics.ParseExporters(getEnv("OTEL_METRICS_EXPORTER", "otlp"))
+	if err != nil {
+		logger.Fatal("Invalid metrics exporter configuration", zap.Error(err))
+	}
+	logsExporter, err := logging.ParseExporterKind(getEnv("OTEL_LOGS_EXPORTER", "otlp"))
+	if err != nil {
+		logger.Fatal("Invalid logs exporter configuration", zap.Error(err))
+	}
+	metricsTemporality, err := metrics.NewTemporalitySelector(
+		getEnv("OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE", "cumulative"),
+		getEnv("METRICS_TEMPORALITY_OVERRIDES", ""),
+	)
+	if err != nil {
+		logger.Fatal("Invalid metrics temporality configuration", zap.Error(err))
+	}
+	metricsOpts := []metrics.Option{
+		// Standard OTEL_METRIC_EXPORT_* variables are expressed in milliseconds
+		metrics.WithExportInterval(time.Duration(getEnvInt(logger, "OTEL_METRIC_EXPORT_INTERVAL", 60000)) * time.Millisecond),
+		metrics.WithExportTimeout(time.Duration(getEnvInt(logger, "OTEL_METRIC_EXPORT_TIMEOUT", 30000)) * time.Millisecond),
+		metrics.WithTemporality(metricsTemporality),
+		metrics.WithRuntimeMetrics(getEnvBool(logger, "METRICS_RUNTIME_ENABLED", true)),
+		metrics.WithProcessMetrics(getEnvBool(logger, "METRICS_PROCESS_ENABLED", true)),
+	}
+	if viewsFile := getEnv("METRICS_VIEWS_FILE", ""); viewsFile != "" {
+		views, err := metrics.LoadViews(viewsFile)
+		if err != nil {
+			logger.Fatal("Invalid metric views configuration", zap.Error(err))
+		}
+		metricsOpts = append(metricsOpts, metrics.WithViews(views...))
+	}
+
+	// Bound the cardinality of the attributes handlers add with AddMetricAttributes. The default
+	// allowlist holds the keys recorded by the handlers of this service
+	attributeLimits := &metrics.AttributeLimits{
+		AllowedKeys:     splitList(getEnv("METRICS_ATTRIBUTE_ALLOWLIST", "user_role,custom")),
+		MaxKeys:         getEnvInt(logger, "METRICS_ATTRIBUTE_MAX_KEYS", 20),
+		MaxValuesPerKey: getEnvInt(logger, "METRICS_ATTRIBUTE_MAX_VALUES", 100),
+	}
+
+	// Initialize metrics and tracing
+	tel, err := telemetry.Setup(context.Background(), telemetry.Config{
+		Endpoint: otelEndpoint,
+		Resource: resource.Config{
+			ServiceName:    serviceName,
+			ServiceVersion: getEnv("SERVICE_VERSION", ""),
+			Environment:    getEnv("DEPLOYMENT_ENVIRONMENT", ""),
+		},
+		TLS:                 otlpTLS,
+		Headers:             otlpHeaders,
+		Token:               otlpToken,
+		RequestCounterName:  requestCounterName,
+		RequestDurationName: requestDurationName,
+		HTTPSemconv:         httpSemconv,
+		Redactor:            redactor,
+		AttributeLimits:     attributeLimits,
+		Tracing:             tracingOpts,
+		Metrics:             metricsOpts,
+		MetricsExporters:    metricsExporters,
+		Logging:             []logging.Option{logging.WithExporter(logsExporter)},
+		AdminAddr:           getEnv("ADMIN_ADDR", ":9464"),
+		ShutdownTimeout:     getEnvDuration(logger, "TELEMETRY_SHUTDOWN_TIMEOUT", 5*time.Second),
+		Logger:              logger,
+	})
 	if err != nil {
-		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+		logger.Fatal("Failed to initialize telemetry", zap.Error(err))
 	}
 	defer func() {
-		if err := tp.Shutdown(context.Background()); err != nil {
-			logger.Error("failed to shutdown trace provider", zap.Error(err))
+		if err := tel.Shutdown(context.Background()); err != nil {
+			logger.Error("failed to shutdown telemetry", zap.Error(err))
 		}
 	}()
 
-	// Set up router
-	r := chi.NewRouter()
-
-	// Use OpenTelemetry middleware for HTTP tracing
-	r.Use(otelhttp.NewMiddleware(serviceName))
+	// From now on, also export log entries as OpenTelemetry log records. Entries are correlated
+	// with the request span only when logged through m.LoggerFromContext, as handlers do
+	otelCore, err := logging.NewCore(tel.LoggerProvider, serviceName, zapcore.InfoLevel)
+	if err != nil {
+		logger.Fatal("Failed to bridge logs to OpenTelemetry", zap.Error(err))
+	}
+	logger = zap.New(zapcore.NewTee(stdoutCore, redact.NewCore(otelCore, redactor)), zap.AddCaller())
+	defer logger.Sync()
+	// m.LoggerFromContext falls back to the global logger outside of LoggingMiddleware
+	zap.ReplaceGlobals(logger)
 
-	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Recoverer)
-	// Add the InitializeLoggingContext and InitializeMetricsContext middleware
-	r.Use(m.InitializeMetricsContext)
-	r.Use(m.InitializeLoggingContext)
-	r.Use(m.TracingMiddleware(tp.Tracer(serviceName)))
-	r.Use(m.MetricsMiddleware(metrics.RequestCounter, metrics.RequestDuration, logger))
-	r.Use(m.LoggingMiddleware(logger))
+	// Headers recorded on spans and access log entries
+	captureRequestHeaders := splitList(getEnv("OTEL_INSTRUMENTATION_HTTP_SERVER_CAPTURE_REQUEST_HEADERS", ""))
+	captureResponseHeaders := splitList(getEnv("OTEL_INSTRUMENTATION_HTTP_SERVER_CAPTURE_RESPONSE_HEADERS", ""))
 
-	r.Get("/hello/{id}", handlers.HelloHandler)
+	spanOpts := []m.TracingOption{
+		m.WithTracingSemconv(httpSemconv),
+		m.WithTracingHeaders(captureRequestHeaders, captureResponseHeaders),
+		m.WithTracingRedactor(redactor),
+		m.WithTraceResponse(getEnvBool(logger, "TRACE_RESPONSE_ENABLED", false)),
+	}
+	if serverTiming {
+		spanOpts = append(spanOpts, m.WithServerTiming(timingRecorder))
+	}
 
-	// Wrap the router in OpenTelemetry instrumentation
-	// 1. All inbound HTTP traffic is traced
-	// 2. Trace context is injected into r.Context()
-	// 3. No per-route setup needed
-	// Use otelhttp and pull chi route pattern for span name
-	wrappedHandler := otelhttp.NewHandler(r, "",
-		otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
-			// Extract the normalized route pattern from the Chi router
-			routePattern := chi.RouteContext(r.Context()).RoutePattern()
-			if routePattern == "" {
-				// Fallback to the raw path if no route pattern is found
-				routePattern = r.URL.Path
-			}
-			return fmt.Sprintf("%s %s", r.Method, routePattern)
-		}),
+	// Set up the router with the tracing, metrics and logging instrumentation
+	r := httpserver.NewRouter(serviceName,
+		httpserver.WithRequestIDHeader(getEnv("REQUEST_ID_HEADER", m.DefaultRequestIDHeader)),
+		httpserver.WithTracing(tel.TracerProvider, spanOpts...),
+		httpserver.WithMetrics(tel.HTTPMetrics),
+		httpserver.WithLogging(logger,
+			m.WithLoggingRedactor(redactor),
+			m.WithLoggingHeaders(captureRequestHeaders, captureResponseHeaders),
+		),
 	)
-	http.Handle("/", wrappedHandler)
+
+	r.Get("/hello/{id}", handlers.HelloHandler)
 
 	// Start server
 	srv := &http.Server{
@@ -143,3 +276,82 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// loadRedactor builds a redactor from the default rules, or from rulesFile when set, exiting on invalid rules.
+func loadRedactor(logger *zap.Logger, rulesFile string) *redact.Redactor {
+	rules := redact.DefaultRules()
+	if rulesFile != "" {
+		var err error
+		if rules, err = redact.LoadRules(rulesFile); err != nil {
+			logger.Fatal("Invalid redaction rules", zap.Error(err))
+		}
+	}
+	redactor, err := redact.New(rules)
+	if err != nil {
+		logger.Fatal("Invalid redaction rules", zap.Error(err))
+	}
+	return redactor
+}
+
+// splitList splits a comma separated list, trimming spaces and skipping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvBool parses a boolean environment variable, exiting on malformed values.
+func getEnvBool(logger *zap.Logger, key string, defaultValue bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		logger.Fatal("Invalid boolean environment variable", zap.String("key", key), zap.Error(err))
+	}
+	return b
+}
+
+// getEnvInt parses an integer environment variable, exiting on malformed values.
+func getEnvInt(logger *zap.Logger, key string, defaultValue int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		logger.Fatal("Invalid integer environment variable", zap.String("key", key), zap.Error(err))
+	}
+	return i
+}
+
+// getEnvFloat parses a floating point environment variable, exiting on malformed values.
+func getEnvFloat(logger *zap.Logger, key string, defaultValue float64) float64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		logger.Fatal("Invalid float environment variable", zap.String("key", key), zap.Error(err))
+	}
+	return f
+}
+
+// getEnvDuration parses a Go duration (e.g. "500ms") environment variable, exiting on malformed values.
+func getEnvDuration(logger *zap.Logger, key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		logger.Fatal("Invalid duration environment variable", zap.String("key", key), zap.Error(err))
+	}
+	return d
+}
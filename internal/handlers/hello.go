@@ -36,6 +36,8 @@ func HelloHandler(w http.ResponseWriter, r *http.Request) {
 	businessSpan.SetAttributes(
 		attribute.String("business.operation", "process_user_data"),
 	)
+	// Log mid-request, correlated with the business logic span
+	middleware.LoggerFromContext(businessCtx).Info("Processed user data")
 	businessSpan.End()
 
 	// Simulate an external API call span
@@ -61,8 +63,8 @@ func HelloHandler(w http.ResponseWriter, r *http.Request) {
 	loggingContext.AddAttribute("user_id", 12345)
 	loggingContext.AddAttribute("custom_key", "custom_value")
 
-	// Set the log level to Info
-	ctx = middleware.WithLogLevel(ctx, zap.InfoLevel)
+	// Set the log level of the access log entry to Info
+	loggingContext.SetLevel(zap.InfoLevel)
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Hello, OpenTelemetry!"))
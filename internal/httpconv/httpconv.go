@@ -0,0 +1,143 @@
+// Package httpconv selects between the legacy attribute names used by this service's HTTP
+// instrumentation and the stable OpenTelemetry HTTP semantic conventions, so that metrics and
+// spans can be migrated to the names expected by OTel ecosystem dashboards.
+package httpconv
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Mode selects which HTTP attribute and metric names are emitted.
+type Mode int
+
+const (
+	// ModeLegacy emits the original names: http.method, http.path and http.status_code
+	// attributes, and the configurable request counter and duration metrics.
+	ModeLegacy Mode = iota
+	// ModeStable emits the stable HTTP semantic conventions: http.request.method, http.route
+	// and http.response.status_code attributes, and the http.server.* metrics.
+	ModeStable
+	// ModeDuplicate emits both the legacy and the stable names, for migrating dashboards.
+	ModeDuplicate
+)
+
+// Stable HTTP server metric names.
+const (
+	ServerRequestDurationName  = "http.server.request.duration"
+	ServerActiveRequestsName   = "http.server.active_requests"
+	ServerRequestBodySizeName  = "http.server.request.body.size"
+	ServerResponseBodySizeName = "http.server.response.body.size"
+)
+
+// DurationBuckets are the explicit bucket boundaries, in seconds, recommended by the semantic
+// conventions for http.server.request.duration.
+var DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+
+// Legacy attribute keys.
+const (
+	legacyMethodKey = attribute.Key("http.method")
+	legacyPathKey   = attribute.Key("http.path")
+	legacyStatusKey = attribute.Key("http.status_code")
+)
+
+// Stable attribute keys.
+const (
+	methodKey = attribute.Key("http.request.method")
+	routeKey  = attribute.Key("http.route")
+	statusKey = attribute.Key("http.response.status_code")
+	schemeKey = attribute.Key("url.scheme")
+)
+
+// ParseMode reads the OTEL_SEMCONV_STABILITY_OPT_IN value, a comma-separated list in which
+// "http" selects ModeStable and "http/dup" selects ModeDuplicate. Anything else keeps ModeLegacy.
+func ParseMode(optIn string) Mode {
+	mode := ModeLegacy
+	for _, v := range strings.Split(optIn, ",") {
+		switch strings.TrimSpace(strings.ToLower(v)) {
+		case "http/dup":
+			return ModeDuplicate
+		case "http":
+			mode = ModeStable
+		}
+	}
+	return mode
+}
+
+// EmitLegacy reports whether the legacy names are emitted.
+func (m Mode) EmitLegacy() bool {
+	return m == ModeLegacy || m == ModeDuplicate
+}
+
+// EmitStable reports whether the stable semantic convention names are emitted.
+func (m Mode) EmitStable() bool {
+	return m == ModeStable || m == ModeDuplicate
+}
+
+// LegacyRequestAttributes returns the legacy attributes known before the response is written.
+func LegacyRequestAttributes(method, route string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		legacyMethodKey.String(method),
+		legacyPathKey.String(route), // Use normalized path
+	}
+}
+
+// LegacyResponseAttributes returns the legacy attributes including the response status code.
+func LegacyResponseAttributes(method, route string, status int) []attribute.KeyValue {
+	return append(LegacyRequestAttributes(method, route), legacyStatusKey.Int(status))
+}
+
+// StableRequestAttributes returns the stable attributes known before the response is written.
+// Methods unknown to the conventions are reported as "_OTHER". http.route is omitted when route
+// is empty, i.e. when the request did not match a route, as the conventions require it to be
+// low cardinality.
+func StableRequestAttributes(method, route, scheme string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{methodKey.String(normalizeMethod(method))}
+	if route != "" {
+		attrs = append(attrs, routeKey.String(route))
+	}
+	return append(attrs, schemeKey.String(scheme))
+}
+
+// StableResponseAttributes returns the stable attributes including the response status code.
+func StableResponseAttributes(method, route, scheme string, status int) []attribute.KeyValue {
+	return append(StableRequestAttributes(method, route, scheme), statusKey.Int(status))
+}
+
+// ResponseAttributes returns the attributes for mode including the response status code.
+// route is empty for requests that did not match a route: the legacy http.path attribute then
+// holds the raw path, as it always did, while the stable http.route attribute is omitted.
+func (m Mode) ResponseAttributes(method, route, path, scheme string, status int) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if m.EmitLegacy() {
+		attrs = append(attrs, LegacyResponseAttributes(method, RouteOrPath(route, path), status)...)
+	}
+	if m.EmitStable() {
+		attrs = append(attrs, StableResponseAttributes(method, route, scheme, status)...)
+	}
+	return attrs
+}
+
+// RouteOrPath returns route, or path when the request did not match a route. Only the legacy
+// attributes fall back to the raw path.
+func RouteOrPath(route, path string) string {
+	if route == "" {
+		return path
+	}
+	return route
+}
+
+// knownMethods are the HTTP methods defined by the semantic conventions.
+var knownMethods = map[string]bool{
+	"CONNECT": true, "DELETE": true, "GET": true, "HEAD": true, "OPTIONS": true,
+	"PATCH": true, "POST": true, "PUT": true, "TRACE": true,
+}
+
+// normalizeMethod maps methods outside the well-known set to "_OTHER" to bound cardinality.
+func normalizeMethod(method string) string {
+	if knownMethods[method] {
+		return method
+	}
+	return "_OTHER"
+}
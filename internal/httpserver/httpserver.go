@@ -0,0 +1,180 @@
+// Package httpserver builds the chi router of the service with a single, well-ordered HTTP
+// instrumentation stack: request IDs, one server span per request, metrics, access logs and
+// panic recovery.
+package httpserver
+
+import (
+	"net/http"
+
+	"opentelemetry-api/internal/metrics"
+	m "opentelemetry-api/internal/middleware"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// config holds the layers installed by NewRouter.
+type config struct {
+	requestID      bool                       // Assign a request ID with RequestIDMiddleware
+	requestIDOpts  []m.RequestIDOption        // Options of RequestIDMiddleware
+	realIP         bool                       // Use X-Forwarded-For / X-Real-IP as remote address
+	recovery       bool                       // Turn panics into 500 responses
+	tracerProvider trace.TracerProvider       // Starts the server span, nil disables tracing
+	tracingOpts    []m.TracingOption          // Options of TracingMiddleware
+	httpMetrics    *metrics.HTTPServerMetrics // Instruments of MetricsMiddleware, nil disables metrics
+	logger         *zap.Logger                // Logger of LoggingMiddleware, nil disables access logs
+	loggingOpts    []m.LoggingOption          // Options of LoggingMiddleware
+}
+
+// Option enables, disables or configures a layer of the router built by NewRouter.
+type Option func(*config)
+
+// WithRequestID enables or disables request ID assignment. Enabled by default.
+func WithRequestID(enabled bool) Option {
+	return func(c *config) {
+		c.requestID = enabled
+	}
+}
+
+// WithRequestIDHeader accepts inbound request IDs from header and echoes the request ID in it.
+// Defaults to middleware.DefaultRequestIDHeader (X-Request-ID).
+func WithRequestIDHeader(header string) Option {
+	return func(c *config) {
+		c.requestIDOpts = append(c.requestIDOpts, m.WithRequestIDHeader(header))
+	}
+}
+
+// WithRealIP enables or disables taking the remote address from X-Forwarded-For or X-Real-IP.
+// Enabled by default; disable it when the service is reachable without a trusted proxy.
+func WithRealIP(enabled bool) Option {
+	return func(c *config) {
+		c.realIP = enabled
+	}
+}
+
+// WithRecovery enables or disables turning handler panics into 500 responses. Enabled by default.
+func WithRecovery(enabled bool) Option {
+	return func(c *config) {
+		c.recovery = enabled
+	}
+}
+
+// WithTracing starts one server span per request from tp and annotates it with
+// TracingMiddleware configured by opts. A nil tp disables tracing. Disabled by default.
+func WithTracing(tp trace.TracerProvider, opts ...m.TracingOption) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+		c.tracingOpts = opts
+	}
+}
+
+// WithMetrics records the HTTP server metrics with MetricsMiddleware. A nil httpMetrics
+// disables metrics. Disabled by default.
+func WithMetrics(httpMetrics *metrics.HTTPServerMetrics) Option {
+	return func(c *config) {
+		c.httpMetrics = httpMetrics
+	}
+}
+
+// WithLogging writes an access log entry per request with LoggingMiddleware configured by opts.
+// A nil logger disables access logs. Disabled by default.
+func WithLogging(logger *zap.Logger, opts ...m.LoggingOption) Option {
+	return func(c *config) {
+		c.logger = logger
+		c.loggingOpts = opts
+	}
+}
+
+// NewRouter returns a chi router with the instrumentation layers selected by opts, installed
+// in this order (outermost first):
+//
+//  1. RealIP, so that every later layer sees the client address
+//  2. otelhttp, the only layer starting a span: exactly one server span per request, which
+//     extracts the incoming trace context (its own metrics are disabled, see MetricsMiddleware)
+//  3. RequestIDMiddleware, recording the request ID on that span and in the baggage, and
+//     making it available to every later layer
+//  4. InitializeMetricsContext and InitializeLoggingContext, always installed so that handlers
+//     can call AddMetricAttributes and GetLoggingContext
+//  5. MetricsMiddleware and LoggingMiddleware, outside of the recoverer so that requests ending
+//     in a panic are still counted and logged with their 500 status
+//  6. Recoverer, turning panics into 500 responses
+//  7. TracingMiddleware, innermost so that it sees panics (recording them on the server span
+//     before re-panicking) and the final route pattern
+//
+// The server span is named once, when otelhttp starts it: "METHOD route" for matched routes and
+// just "METHOD" otherwise, so that probes of unknown paths do not create new span names.
+//
+// Register routes on the returned router as usual.
+//
+// Example usage:
+//
+//	r := httpserver.NewRouter("my-api",
+//		httpserver.WithTracing(tel.TracerProvider, middleware.WithTracingSemconv(mode)),
+//		httpserver.WithMetrics(tel.HTTPMetrics),
+//		httpserver.WithLogging(logger),
+//	)
+//	r.Get("/hello/{id}", handlers.HelloHandler)
+//	http.ListenAndServe(":8080", r)
+func NewRouter(serviceName string, opts ...Option) *chi.Mux {
+	cfg := config{requestID: true, realIP: true, recovery: true}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	r := chi.NewRouter()
+
+	if cfg.realIP {
+		r.Use(middleware.RealIP)
+	}
+	if cfg.tracerProvider != nil {
+		r.Use(otelhttp.NewMiddleware(serviceName,
+			otelhttp.WithTracerProvider(cfg.tracerProvider),
+			// HTTP server metrics are recorded by MetricsMiddleware with the configured names
+			otelhttp.WithMeterProvider(noop.NewMeterProvider()),
+			// Name the span after the route it is about to be dispatched to; samplers see this name
+			otelhttp.WithSpanNameFormatter(spanName),
+		))
+	}
+	if cfg.requestID {
+		r.Use(m.RequestIDMiddleware(cfg.requestIDOpts...))
+	}
+	r.Use(m.InitializeMetricsContext)
+	r.Use(m.InitializeLoggingContext)
+	if cfg.httpMetrics != nil {
+		r.Use(m.MetricsMiddleware(cfg.httpMetrics, loggerOrNop(cfg.logger)))
+	}
+	if cfg.logger != nil {
+		r.Use(m.LoggingMiddleware(cfg.logger, cfg.loggingOpts...))
+	}
+	if cfg.recovery {
+		r.Use(middleware.Recoverer)
+	}
+	if cfg.tracerProvider != nil {
+		r.Use(m.TracingMiddleware(cfg.tracingOpts...))
+	}
+	return r
+}
+
+// spanName names the server span "METHOD route" using the chi router handling the request,
+// which has not routed it yet when the span starts. It falls back to the method alone, as
+// recommended for unmatched routes, to keep span names low cardinality.
+func spanName(_ string, r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.Routes != nil {
+		if pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path); pattern != "" {
+			return r.Method + " " + pattern
+		}
+	}
+	return r.Method
+}
+
+// loggerOrNop returns logger, or a no-op logger when it is nil.
+func loggerOrNop(logger *zap.Logger) *zap.Logger {
+	if logger == nil {
+		return zap.NewNop()
+	}
+	return logger
+}
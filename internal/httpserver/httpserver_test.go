@@ -0,0 +1,95 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func newTestRouter(t *testing.T) (http.Handler, *tracetest.SpanRecorder) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	r := NewRouter("test-service", WithTracing(tp), WithLogging(zap.NewNop()))
+	r.Get("/hello/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	})
+	r.Get("/panic", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+	return r, recorder
+}
+
+func TestNewRouterStartsOneServerSpanPerRequest(t *testing.T) {
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantName   string
+	}{
+		{path: "/hello/42", wantStatus: http.StatusOK, wantName: "GET /hello/{id}"},
+		{path: "/nothere", wantStatus: http.StatusNotFound, wantName: "GET"},
+		{path: "/panic", wantStatus: http.StatusInternalServerError, wantName: "GET /panic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r, recorder := newTestRouter(t)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want exactly 1", len(spans))
+			}
+			if spans[0].SpanKind() != trace.SpanKindServer {
+				t.Errorf("span kind = %v, want server", spans[0].SpanKind())
+			}
+			if spans[0].Name() != tt.wantName {
+				t.Errorf("span name = %q, want %q", spans[0].Name(), tt.wantName)
+			}
+		})
+	}
+}
+
+func TestNewRouterWithoutTracingStartsNoSpan(t *testing.T) {
+	// Record the spans of the global provider too, in case a layer falls back to it
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = tp.Shutdown(context.Background())
+	})
+
+	tests := map[string][]Option{
+		"default":         nil,
+		"nil provider":    {WithTracing(nil)},
+		"disabled later":  {WithTracing(tp), WithTracing(nil)},
+		"with other opts": {WithLogging(zap.NewNop()), WithRequestID(true)},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			recorder.Reset()
+			r := NewRouter("test-service", opts...)
+			r.Get("/hello", func(http.ResponseWriter, *http.Request) {})
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello", nil))
+
+			if n := len(recorder.Ended()); n != 0 {
+				t.Errorf("got %d spans, want none", n)
+			}
+		})
+	}
+}
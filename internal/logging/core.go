@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/contrib/bridges/otelzap"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// contextFieldKey is the key of the field added by Context. The field is never encoded.
+const contextFieldKey = "context"
+
+// NewCore returns a zapcore.Core that forwards entries at or above level to lp as OpenTelemetry
+// log records. Tee it with the stdout core so that entries keep being written locally:
+//
+//	otelCore, err := NewCore(lp, "my-api", zapcore.InfoLevel)
+//	if err != nil {
+//	    return err
+//	}
+//	logger := zap.New(zapcore.NewTee(WithTraceContext(stdoutCore), otelCore), zap.AddCaller())
+//
+// Records carry the trace and span IDs of the span found in a context passed with Context only:
+// zap entries have no context of their own, so entries logged without it are not correlated.
+// Within a request, log through middleware.LoggerFromContext, which adds it.
+func NewCore(lp log.LoggerProvider, name string, level zapcore.LevelEnabler) (zapcore.Core, error) {
+	core, err := zapcore.NewIncreaseLevelCore(otelzap.NewCore(name, otelzap.WithLoggerProvider(lp)), level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OpenTelemetry log core: %w", err)
+	}
+	return core, nil
+}
+
+// Context returns a field carrying ctx. It is not written by encoders; instead the core
+// returned by NewCore emits the record with ctx, correlating it with the active span, and
+// WithTraceContext turns it into trace_id and span_id fields. Correlation is not automatic:
+// every entry to correlate needs this field, which middleware.LoggerFromContext adds to the
+// loggers it returns.
+//
+// Example usage:
+//
+//	logger.Info("order created", logging.Context(r.Context()), zap.String("order_id", id))
+//	// or, equivalently within a request:
+//	middleware.LoggerFromContext(r.Context()).Info("order created", zap.String("order_id", id))
+func Context(ctx context.Context) zap.Field {
+	return zap.Field{Key: contextFieldKey, Type: zapcore.SkipType, Interface: ctx}
+}
+
+// traceContextCore adds trace_id and span_id fields for contexts passed with Context.
+type traceContextCore struct {
+	zapcore.Core
+}
+
+// WithTraceContext wraps core so that entries logged with a Context field holding a span get
+// "trace_id" and "span_id" fields, correlating plain (e.g. stdout JSON) logs with traces.
+func WithTraceContext(core zapcore.Core) zapcore.Core {
+	return traceContextCore{Core: core}
+}
+
+// With implements zapcore.Core.
+func (c traceContextCore) With(fields []zapcore.Field) zapcore.Core {
+	return traceContextCore{Core: c.Core.With(appendTraceFields(fields))}
+}
+
+// Check implements zapcore.Core.
+func (c traceContextCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core.
+func (c traceContextCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, appendTraceFields(fields))
+}
+
+// appendTraceFields returns fields with trace_id and span_id appended when one of them is a
+// Context field holding a valid span context.
+func appendTraceFields(fields []zapcore.Field) []zapcore.Field {
+	for _, f := range fields {
+		if f.Type != zapcore.SkipType || f.Key != contextFieldKey {
+			continue
+		}
+		ctx, ok := f.Interface.(context.Context)
+		if !ok {
+			continue
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			return append(fields[:len(fields):len(fields)],
+				zap.String("trace_id", sc.TraceID().String()),
+				zap.String("span_id", sc.SpanID().String()),
+			)
+		}
+	}
+	return fields
+}
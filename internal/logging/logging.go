@@ -0,0 +1,121 @@
+// Package logging exports zap log entries as OpenTelemetry log records next to the existing
+// stdout output. Entries are correlated with a span when they carry its context (see Context),
+// as those logged through middleware.LoggerFromContext do.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"opentelemetry-api/internal/otlpconfig"
+	"opentelemetry-api/internal/resource"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// ExporterKind identifies the backend InitLogger ships log records to.
+type ExporterKind string
+
+const (
+	ExporterOTLP ExporterKind = "otlp" // OTLP over gRPC (default)
+	ExporterNone ExporterKind = "none" // Log records are only written to stdout
+)
+
+// ParseExporterKind maps the standard OTEL_LOGS_EXPORTER value to an ExporterKind.
+// Supported exporters are "otlp" (the default when empty) and "none".
+//
+// Example usage:
+//
+//	kind, err := ParseExporterKind(os.Getenv("OTEL_LOGS_EXPORTER"))
+//	if err != nil {
+//	    log.Fatalf("invalid logs exporter configuration: %v", err)
+//	}
+func ParseExporterKind(exporter string) (ExporterKind, error) {
+	switch strings.ToLower(strings.TrimSpace(exporter)) {
+	case "", "otlp":
+		return ExporterOTLP, nil
+	case "none":
+		return ExporterNone, nil
+	default:
+		return "", fmt.Errorf("unsupported logs exporter %q (expected otlp or none)", exporter)
+	}
+}
+
+// InitLogger initializes an OpenTelemetry LoggerProvider exporting log records over OTLP/gRPC
+// and installs it as the global LoggerProvider. Bridge zap to it with NewCore.
+//
+// Parameters:
+//   - endpoint: The OTLP endpoint to which log records will be exported (e.g., "localhost:4317").
+//   - serviceName: The name of the service (e.g., "my-api").
+//   - opts: Optional settings such as WithTLS. By default log records are exported over plaintext gRPC.
+//
+// Returns:
+//   - *sdklog.LoggerProvider: The initialized LoggerProvider; shut it down to flush buffered records.
+//   - error: An error if the initialization fails at any step.
+//
+// Example usage:
+//
+//	lp, err := InitLogger("localhost:4317", "my-api")
+//	if err != nil {
+//	    log.Fatalf("failed to initialize logs: %v", err)
+//	}
+//	defer lp.Shutdown(context.Background())
+func InitLogger(endpoint, serviceName string, opts ...Option) (*sdklog.LoggerProvider, error) {
+	ctx := context.Background()
+	cfg := newConfig(opts...)
+
+	// Use the shared resource, or detect one describing the application (e.g., service name)
+	res := cfg.resource
+	if res == nil {
+		var err error
+		if res, err = resource.New(ctx, resource.Config{ServiceName: serviceName}); err != nil {
+			return nil, err
+		}
+	}
+	providerOpts := []sdklog.LoggerProviderOption{sdklog.WithResource(res)}
+
+	if cfg.exporter != ExporterNone {
+		exporter, err := newExporter(ctx, cfg, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		// Batch records in the background so that logging never blocks on the network
+		providerOpts = append(providerOpts, sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)))
+	}
+
+	lp := sdklog.NewLoggerProvider(providerOpts...)
+	// Set the global LoggerProvider so that other bridges can use it
+	global.SetLoggerProvider(lp)
+	return lp, nil
+}
+
+// newExporter builds the log record exporter selected by cfg.
+func newExporter(ctx context.Context, cfg config, endpoint string) (sdklog.Exporter, error) {
+	switch cfg.exporter {
+	case ExporterOTLP:
+		transport, err := otlpconfig.NewGRPCTransport(cfg.tls, cfg.token)
+		if err != nil {
+			return nil, err
+		}
+		opts := []otlploggrpc.Option{
+			otlploggrpc.WithEndpoint(endpoint),
+			otlploggrpc.WithHeaders(cfg.headers),
+			otlploggrpc.WithDialOption(transport.DialOptions...),
+		}
+		if transport.Credentials == nil {
+			opts = append(opts, otlploggrpc.WithInsecure())
+		} else {
+			opts = append(opts, otlploggrpc.WithTLSCredentials(transport.Credentials))
+		}
+		exporter, err := otlploggrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP log exporter: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("unsupported logs exporter kind %q", cfg.exporter)
+	}
+}
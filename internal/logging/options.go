@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"opentelemetry-api/internal/otlpconfig"
+
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+)
+
+// config holds the optional settings applied by InitLogger.
+type config struct {
+	exporter ExporterKind          // Which exporter log records are shipped to
+	tls      otlpconfig.TLSConfig  // Transport security for the OTLP exporter
+	headers  map[string]string     // Extra headers sent with every OTLP export
+	token    *otlpconfig.TokenFile // Bearer token attached to every OTLP export, nil when unset
+	resource *sdkresource.Resource // Resource describing the service, nil to detect one
+}
+
+// newConfig returns the default configuration with opts applied on top.
+func newConfig(opts ...Option) config {
+	cfg := config{
+		exporter: ExporterOTLP,
+		tls:      otlpconfig.TLSConfig{Insecure: true},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// Option customizes the LoggerProvider built by InitLogger.
+type Option func(*config)
+
+// WithExporter selects the log record exporter. Defaults to ExporterOTLP.
+func WithExporter(kind ExporterKind) Option {
+	return func(c *config) {
+		c.exporter = kind
+	}
+}
+
+// WithTLS configures transport security for the OTLP log exporter.
+// Defaults to an insecure (plaintext) connection.
+func WithTLS(tls otlpconfig.TLSConfig) Option {
+	return func(c *config) {
+		c.tls = tls
+	}
+}
+
+// WithHeaders adds headers, e.g. parsed by otlpconfig.ParseHeaders, to every OTLP log exporter call.
+func WithHeaders(headers map[string]string) Option {
+	return func(c *config) {
+		c.headers = headers
+	}
+}
+
+// WithTokenFile sends the token from token as an "authorization: Bearer" header with every
+// OTLP log exporter call. The file is re-read when it changes.
+func WithTokenFile(token *otlpconfig.TokenFile) Option {
+	return func(c *config) {
+		c.token = token
+	}
+}
+
+// WithResource sets the resource attached to exported log records, so that logs are grouped
+// under the same service as the spans they are correlated with. By default InitLogger detects
+// a resource for its serviceName.
+func WithResource(res *sdkresource.Resource) Option {
+	return func(c *config) {
+		c.resource = res
+	}
+}
@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// LimitedAttributesName is the name of the counter reporting custom attributes that were
+// dropped or folded by an AttributeLimiter.
+const LimitedAttributesName = "metric_attributes_limited_total"
+
+// DefaultOverflowValue replaces attribute values beyond AttributeLimits.MaxValuesPerKey.
+const DefaultOverflowValue = "other"
+
+// AttributeLimits bounds the cardinality of the custom attributes handlers attach to the
+// HTTP server metrics through middleware.AddMetricAttributes.
+type AttributeLimits struct {
+	AllowedKeys     []string // Keys that may be recorded; attributes with other keys are dropped. Empty allows every key, up to MaxKeys
+	MaxKeys         int      // Distinct keys recorded before attributes with new keys are dropped (default 20)
+	MaxValuesPerKey int      // Distinct values recorded per key before new values are folded (default 100)
+	OverflowValue   string   // Value that replaces folded values (default DefaultOverflowValue)
+}
+
+// AttributeLimiter enforces AttributeLimits on attribute sets. The distinct keys and values are
+// remembered for the lifetime of the limiter, so it holds at most MaxKeys × MaxValuesPerKey
+// values: once MaxKeys keys have been seen, attributes with any other key are dropped, and once a
+// key has seen MaxValuesPerKey values, every new value is recorded as OverflowValue, while the
+// keys and values seen so far keep their own series.
+//
+// A nil *AttributeLimiter passes attributes through unchanged.
+type AttributeLimiter struct {
+	allowed   map[attribute.Key]struct{} // nil when every key is allowed
+	maxKeys   int
+	maxValues int
+	overflow  attribute.Value
+
+	mu     sync.Mutex
+	values map[attribute.Key]map[string]struct{} // Distinct values seen per key
+
+	limited metric.Int64Counter // Attributes dropped or folded, by action
+}
+
+// NewAttributeLimiter creates an AttributeLimiter reporting the attributes it limits on a
+// counter created from meter.
+//
+// Example usage:
+//
+//	limiter, err := NewAttributeLimiter(mp.Meter("my-api"), AttributeLimits{
+//	    AllowedKeys:     []string{"user_role", "tenant"},
+//	    MaxValuesPerKey: 50,
+//	})
+//	if err != nil {
+//	    logger.Fatal("failed to create attribute limiter", zap.Error(err))
+//	}
+//	attrs = limiter.Limit(ctx, attrs)
+func NewAttributeLimiter(meter metric.Meter, limits AttributeLimits) (*AttributeLimiter, error) {
+	if limits.MaxKeys <= 0 {
+		limits.MaxKeys = 20
+	}
+	if limits.MaxValuesPerKey <= 0 {
+		limits.MaxValuesPerKey = 100
+	}
+	if limits.OverflowValue == "" {
+		limits.OverflowValue = DefaultOverflowValue
+	}
+
+	l := &AttributeLimiter{
+		maxKeys:   limits.MaxKeys,
+		maxValues: limits.MaxValuesPerKey,
+		overflow:  attribute.StringValue(limits.OverflowValue),
+		values:    make(map[attribute.Key]map[string]struct{}),
+	}
+	if len(limits.AllowedKeys) > 0 {
+		l.allowed = make(map[attribute.Key]struct{}, len(limits.AllowedKeys))
+		for _, k := range limits.AllowedKeys {
+			l.allowed[attribute.Key(k)] = struct{}{}
+		}
+	}
+
+	var err error
+	l.limited, err = meter.Int64Counter(
+		LimitedAttributesName,
+		metric.WithDescription("Custom metric attributes dropped because their key is not allowed or exceeds the key limit, or folded because their key exceeded its distinct value limit"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Limit returns attrs with disallowed or overflowing keys removed and overflowing values folded.
+// The input slice is not modified.
+func (l *AttributeLimiter) Limit(ctx context.Context, attrs []attribute.KeyValue) []attribute.KeyValue {
+	if l == nil || len(attrs) == 0 {
+		return attrs
+	}
+
+	var dropped, folded int64
+	limited := make([]attribute.KeyValue, 0, len(attrs))
+
+	l.mu.Lock()
+	for _, kv := range attrs {
+		if l.allowed != nil {
+			if _, ok := l.allowed[kv.Key]; !ok {
+				dropped++
+				continue
+			}
+		}
+
+		seen, ok := l.values[kv.Key]
+		if !ok {
+			if len(l.values) >= l.maxKeys {
+				dropped++
+				continue
+			}
+			seen = make(map[string]struct{})
+			l.values[kv.Key] = seen
+		}
+		value := kv.Value.Emit()
+		if _, ok := seen[value]; !ok {
+			if len(seen) >= l.maxValues {
+				folded++
+				kv.Value = l.overflow
+			} else {
+				seen[value] = struct{}{}
+			}
+		}
+		limited = append(limited, kv)
+	}
+	l.mu.Unlock()
+
+	if dropped > 0 {
+		l.limited.Add(ctx, dropped, metric.WithAttributes(attribute.String("action", "dropped")))
+	}
+	if folded > 0 {
+		l.limited.Add(ctx, folded, metric.WithAttributes(attribute.String("action", "folded")))
+	}
+	return limited
+}
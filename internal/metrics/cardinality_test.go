@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// newTestLimiter returns an AttributeLimiter for limits and a function returning the
+// metric_attributes_limited_total counts by action.
+func newTestLimiter(t *testing.T, limits AttributeLimits) (*AttributeLimiter, func() map[string]int64) {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	limiter, err := NewAttributeLimiter(mp.Meter("test"), limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := func() map[string]int64 {
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(context.Background(), &rm); err != nil {
+			t.Fatal(err)
+		}
+		counts := make(map[string]int64)
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if m.Name != LimitedAttributesName {
+					continue
+				}
+				for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+					action, _ := dp.Attributes.Value("action")
+					counts[action.AsString()] += dp.Value
+				}
+			}
+		}
+		return counts
+	}
+	return limiter, counts
+}
+
+func TestAttributeLimiterAllowlist(t *testing.T) {
+	limiter, counts := newTestLimiter(t, AttributeLimits{AllowedKeys: []string{"tenant"}})
+
+	input := []attribute.KeyValue{attribute.String("tenant", "acme"), attribute.String("user_id", "42")}
+	got := limiter.Limit(context.Background(), input)
+
+	if want := []attribute.KeyValue{attribute.String("tenant", "acme")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Limit() = %v, want %v", got, want)
+	}
+	if len(input) != 2 {
+		t.Error("Limit() modified its input")
+	}
+	if got := counts(); got["dropped"] != 1 || got["folded"] != 0 {
+		t.Errorf("limited counts = %v, want one dropped", got)
+	}
+}
+
+func TestAttributeLimiterFoldsOverflowingValues(t *testing.T) {
+	limiter, counts := newTestLimiter(t, AttributeLimits{MaxValuesPerKey: 2})
+	ctx := context.Background()
+
+	tests := []struct {
+		value, want string
+	}{
+		{"a", "a"},
+		{"b", "b"},
+		{"c", DefaultOverflowValue}, // Third distinct value
+		{"a", "a"},                  // Values seen before the limit keep their series
+		{"d", DefaultOverflowValue},
+	}
+	for _, tt := range tests {
+		got := limiter.Limit(ctx, []attribute.KeyValue{attribute.String("tenant", tt.value)})
+		if len(got) != 1 || got[0].Value.AsString() != tt.want {
+			t.Errorf("Limit(tenant=%s) = %v, want tenant=%s", tt.value, got, tt.want)
+		}
+	}
+	if got := counts(); got["folded"] != 2 {
+		t.Errorf("limited counts = %v, want two folded", got)
+	}
+}
+
+func TestAttributeLimiterCapsKeys(t *testing.T) {
+	limiter, counts := newTestLimiter(t, AttributeLimits{MaxKeys: 2})
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		limiter.Limit(ctx, []attribute.KeyValue{attribute.Int(fmt.Sprintf("key%d", i), i)})
+	}
+	got := limiter.Limit(ctx, []attribute.KeyValue{attribute.Int("key0", 7), attribute.Int("key9", 9)})
+
+	if want := []attribute.KeyValue{attribute.Int("key0", 7)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Limit() = %v, want %v", got, want)
+	}
+	if n := len(limiter.values); n != 2 {
+		t.Errorf("limiter tracks %d keys, want MaxKeys (2)", n)
+	}
+	if got := counts(); got["dropped"] != 4 {
+		t.Errorf("limited counts = %v, want four dropped", got)
+	}
+}
+
+func TestAttributeLimiterConcurrentUse(t *testing.T) {
+	limiter, _ := newTestLimiter(t, AttributeLimits{MaxKeys: 4, MaxValuesPerKey: 10})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				limiter.Limit(ctx, []attribute.KeyValue{attribute.Int(fmt.Sprintf("key%d", (g+i)%8), i)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(limiter.values); n != 4 {
+		t.Errorf("limiter tracks %d keys, want 4", n)
+	}
+	for key, seen := range limiter.values {
+		if len(seen) != 10 {
+			t.Errorf("key %s tracks %d values, want 10", key, len(seen))
+		}
+	}
+}
+
+func TestNilAttributeLimiter(t *testing.T) {
+	var limiter *AttributeLimiter
+	attrs := []attribute.KeyValue{attribute.String("user_id", "42")}
+	if got := limiter.Limit(context.Background(), attrs); !reflect.DeepEqual(got, attrs) {
+		t.Errorf("Limit() = %v, want the input unchanged", got)
+	}
+}
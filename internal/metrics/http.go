@@ -0,0 +1,209 @@
+package metrics
+
+import (
+	"opentelemetry-api/internal/httpconv"
+	"opentelemetry-api/internal/redact"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Instrument names for the HTTP server metrics that are not configurable.
+const (
+	ActiveRequestsName = "http_server_active_requests"
+	RequestSizeName    = "http_request_size_bytes"
+	ResponseSizeName   = "http_response_size_bytes"
+)
+
+// HTTPServerMetrics groups the instruments recorded for every HTTP request by
+// middleware.MetricsMiddleware. Each router can own its own set, created from any
+// metric.MeterProvider, which keeps tests and multiple servers independent of global state.
+//
+// Which instruments exist depends on Mode: the legacy instruments are only created when
+// Mode.EmitLegacy() and the stable semantic convention (http.server.*) instruments only when
+// Mode.EmitStable(). The others are left nil.
+type HTTPServerMetrics struct {
+	Mode httpconv.Mode // Attribute and metric naming in use
+
+	// Legacy instruments
+	RequestCounter  metric.Int64Counter       // Total number of HTTP requests
+	RequestDuration metric.Float64Histogram   // Duration of HTTP requests in seconds
+	ActiveRequests  metric.Int64UpDownCounter // Number of requests currently being served
+	RequestSize     metric.Int64Histogram     // Size of request bodies in bytes
+	ResponseSize    metric.Int64Histogram     // Size of response bodies in bytes
+
+	// Stable semantic convention instruments
+	ServerRequestDuration  metric.Float64Histogram   // http.server.request.duration
+	ServerActiveRequests   metric.Int64UpDownCounter // http.server.active_requests
+	ServerRequestBodySize  metric.Int64Histogram     // http.server.request.body.size
+	ServerResponseBodySize metric.Int64Histogram     // http.server.response.body.size
+
+	// CustomAttributes bounds the attributes handlers add with middleware.AddMetricAttributes.
+	// Nil when no limits are configured, in which case custom attributes are recorded as is.
+	CustomAttributes *AttributeLimiter
+
+	// Redactor redacts the values of custom attributes before they are limited and recorded.
+	// Nil when no redaction is configured.
+	Redactor *redact.Redactor
+}
+
+// httpServerMetricsConfig holds the optional settings applied by NewHTTPServerMetrics.
+type httpServerMetricsConfig struct {
+	mode     httpconv.Mode
+	limits   *AttributeLimits
+	redactor *redact.Redactor
+}
+
+// HTTPServerMetricsOption customizes the instruments created by NewHTTPServerMetrics.
+type HTTPServerMetricsOption func(*httpServerMetricsConfig)
+
+// WithSemconvMode selects legacy, stable or duplicated metric names. Defaults to httpconv.ModeLegacy.
+func WithSemconvMode(mode httpconv.Mode) HTTPServerMetricsOption {
+	return func(c *httpServerMetricsConfig) {
+		c.mode = mode
+	}
+}
+
+// WithAttributeLimits bounds the cardinality of custom attributes with an AttributeLimiter.
+// By default custom attributes are recorded without limits.
+func WithAttributeLimits(limits AttributeLimits) HTTPServerMetricsOption {
+	return func(c *httpServerMetricsConfig) {
+		c.limits = &limits
+	}
+}
+
+// WithRedactor redacts the values of custom attributes (e.g. e-mail addresses) before they are
+// recorded. By default custom attributes are recorded as is.
+func WithRedactor(redactor *redact.Redactor) HTTPServerMetricsOption {
+	return func(c *httpServerMetricsConfig) {
+		c.redactor = redactor
+	}
+}
+
+// NewHTTPServerMetrics creates the HTTP server instruments from mp.
+//
+// Parameters:
+//   - mp: The MeterProvider the instruments are created from (e.g. the one returned by InitMetrics).
+//   - meterName: The name of the Meter, usually the service name.
+//   - requestCounterName: The name of the counter metric for tracking the total number of HTTP requests.
+//   - requestDurationName: The name of the histogram metric for tracking the duration of HTTP requests.
+//   - opts: Optional settings such as WithSemconvMode and WithAttributeLimits.
+//
+// Example usage:
+//
+//	httpMetrics, err := NewHTTPServerMetrics(mp, "my-api", "http_requests_total", "http_request_duration_seconds")
+//	if err != nil {
+//	    logger.Fatal("failed to create HTTP metrics", zap.Error(err))
+//	}
+//	r.Use(middleware.MetricsMiddleware(httpMetrics, logger))
+func NewHTTPServerMetrics(mp metric.MeterProvider, meterName, requestCounterName, requestDurationName string, opts ...HTTPServerMetricsOption) (*HTTPServerMetrics, error) {
+	var cfg httpServerMetricsConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	meter := mp.Meter(meterName)
+	m := &HTTPServerMetrics{Mode: cfg.mode, Redactor: cfg.redactor}
+
+	if cfg.mode.EmitLegacy() {
+		if err := m.initLegacy(meter, requestCounterName, requestDurationName); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.mode.EmitStable() {
+		if err := m.initStable(meter); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.limits != nil {
+		var err error
+		if m.CustomAttributes, err = NewAttributeLimiter(meter, *cfg.limits); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// initLegacy creates the instruments with the original, service specific names.
+func (m *HTTPServerMetrics) initLegacy(meter metric.Meter, requestCounterName, requestDurationName string) error {
+	var err error
+	// Define an Int64Counter to track the total number of HTTP requests
+	m.RequestCounter, err = meter.Int64Counter(
+		requestCounterName,
+		metric.WithDescription("Total number of HTTP requests"),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Define a Float64Histogram to track the duration of HTTP requests, with buckets suited to seconds
+	m.RequestDuration, err = meter.Float64Histogram(
+		requestDurationName,
+		metric.WithDescription("Histogram of response time for handler in seconds"),
+		metric.WithExplicitBucketBoundaries(httpconv.DurationBuckets...),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Define an Int64UpDownCounter to track the number of concurrent requests
+	m.ActiveRequests, err = meter.Int64UpDownCounter(
+		ActiveRequestsName,
+		metric.WithDescription("Number of HTTP requests currently being served"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Define Int64Histograms to track the size of request and response bodies
+	m.RequestSize, err = meter.Int64Histogram(
+		RequestSizeName,
+		metric.WithDescription("Size of HTTP request bodies in bytes"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return err
+	}
+	m.ResponseSize, err = meter.Int64Histogram(
+		ResponseSizeName,
+		metric.WithDescription("Size of HTTP response bodies in bytes"),
+		metric.WithUnit("By"),
+	)
+	return err
+}
+
+// initStable creates the instruments defined by the stable HTTP semantic conventions.
+func (m *HTTPServerMetrics) initStable(meter metric.Meter) error {
+	var err error
+	m.ServerRequestDuration, err = meter.Float64Histogram(
+		httpconv.ServerRequestDurationName,
+		metric.WithDescription("Duration of HTTP server requests."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(httpconv.DurationBuckets...),
+	)
+	if err != nil {
+		return err
+	}
+	m.ServerActiveRequests, err = meter.Int64UpDownCounter(
+		httpconv.ServerActiveRequestsName,
+		metric.WithDescription("Number of active HTTP server requests."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return err
+	}
+	m.ServerRequestBodySize, err = meter.Int64Histogram(
+		httpconv.ServerRequestBodySizeName,
+		metric.WithDescription("Size of HTTP server request bodies."),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return err
+	}
+	m.ServerResponseBodySize, err = meter.Int64Histogram(
+		httpconv.ServerResponseBodySizeName,
+		metric.WithDescription("Size of HTTP server response bodies."),
+		metric.WithUnit("By"),
+	)
+	return err
+}
@@ -2,26 +2,32 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
+
+	"opentelemetry-api/internal/httpconv"
+	"opentelemetry-api/internal/otlpconfig"
+	"opentelemetry-api/internal/resource"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/metric"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
-	"go.opentelemetry.io/otel/sdk/resource"
-	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 	"go.uber.org/zap"
 )
 
-// Global variables for metrics
+// Global variables for metrics, set as a side effect of InitMetrics. They are nil when
+// WithHTTPSemconv selects the stable conventions only.
+//
+// Deprecated: Create an HTTPServerMetrics with NewHTTPServerMetrics and pass it to
+// middleware.MetricsMiddleware instead. These globals only exist for backward compatibility.
 var (
 	RequestCounter  metric.Int64Counter     // Counter to track the total number of HTTP requests
 	RequestDuration metric.Float64Histogram // Histogram to track the duration of HTTP requests
 )
 
 // InitMetrics initializes and configures an OpenTelemetry MeterProvider for metrics.
-// It sets up an OTLP metric exporter, a resource with service attributes, and a meter provider
+// It sets up an OTLP metric exporter (plus any additional readers such as the Prometheus one), a resource with service attributes, and a meter provider
 // with periodic reading and exporting configurations. Additionally, it configures the global meter provider
 // and defines common metrics for tracking HTTP requests.
 //
@@ -31,6 +37,8 @@ var (
 //   - requestCounterName: The name of the counter metric for tracking the total number of HTTP requests.
 //   - requestDurationName: The name of the histogram metric for tracking the duration of HTTP requests.
 //   - logger: A zap.Logger instance for logging errors and information.
+//   - opts: Optional settings such as WithTLS, WithViews, WithReader, WithExportInterval and WithHTTPSemconv. By
+//     default metrics are exported over plaintext gRPC every 60 seconds with cumulative temporality.
 //
 // Returns:
 //   - *sdkmetric.MeterProvider: The initialized MeterProvider instance, which manages metric instruments and readers.
@@ -44,7 +52,8 @@ var (
 //	}
 //	defer mp.Shutdown(context.Background())
 //
-// The function also defines two global metrics:
+// Unless WithHTTPSemconv selects the stable conventions only, the function also defines two
+// deprecated global metrics, kept for backward compatibility (use NewHTTPServerMetrics instead):
 //   - RequestCounter: An Int64Counter to track the total number of HTTP requests.
 //   - RequestDuration: A Float64Histogram to track the duration of HTTP requests in seconds.
 //
@@ -52,62 +61,110 @@ var (
 //   - The OTLP endpoint must be reachable by the application.
 //   - The service name is used to identify the application in observability tools.
 //   - The global MeterProvider is set so that it can be used throughout the application.
-func InitMetrics(endpoint, serviceName, requestCounterName, requestDurationName string, logger *zap.Logger) (*sdkmetric.MeterProvider, error) {
+//   - If an error occurs once the MeterProvider is built, it is shut down (with its readers)
+//     before the error is returned, and the global MeterProvider is left unchanged.
+func InitMetrics(endpoint, serviceName, requestCounterName, requestDurationName string, logger *zap.Logger, opts ...Option) (*sdkmetric.MeterProvider, error) {
 	ctx := context.Background()
+	cfg := newConfig(opts...)
 
-	// Create OTLP metric exporter to send metrics to the specified endpoint
-	metricExporter, err := otlpmetricgrpc.New(ctx,
-		otlpmetricgrpc.WithEndpoint(endpoint), // Specify the OTLP endpoint
-		otlpmetricgrpc.WithInsecure(),         // Use insecure connection (no TLS)
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	// Use the shared resource, or detect one describing the application (e.g., service name)
+	res := cfg.resource
+	if res == nil {
+		var err error
+		res, err = resource.New(ctx, resource.Config{ServiceName: serviceName})
+		if err != nil {
+			// Log the error if resource creation fails
+			logger.Error("failed to create resource", zap.Error(err))
+			return nil, err
+		}
 	}
 
-	// Create a periodic reader to collect and export metrics at regular intervals
-	reader := sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(3*time.Second))
-
-	// Create a resource to describe the application (e.g., service name)
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceNameKey.String(serviceName), // Use the provided service name
-		),
-	)
-	if err != nil {
-		// Log the error if resource creation fails
-		logger.Error("failed to create resource", zap.Error(err))
-		return nil, err
+	// Collect the readers metrics are exported through: the OTLP push reader and any pull readers
+	readerOpts := make([]sdkmetric.Option, 0, len(cfg.readers)+1)
+	if cfg.otlp {
+		metricExporter, err := newOTLPExporter(ctx, cfg, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		// Create a periodic reader to collect and export metrics at regular intervals
+		reader := sdkmetric.NewPeriodicReader(metricExporter,
+			sdkmetric.WithInterval(cfg.interval),
+			sdkmetric.WithTimeout(cfg.timeout),
+		)
+		readerOpts = append(readerOpts, sdkmetric.WithReader(reader))
+	}
+	for _, reader := range cfg.readers {
+		readerOpts = append(readerOpts, sdkmetric.WithReader(reader))
 	}
 
 	// Create a MeterProvider to manage metric instruments and readers
-	mp := sdkmetric.NewMeterProvider(
-		sdkmetric.WithReader(reader), // Attach the periodic reader for exporting metrics
-		sdkmetric.WithResource(res),  // Attach the resource describing the application
-	)
+	mp := sdkmetric.NewMeterProvider(append(readerOpts,
+		sdkmetric.WithResource(res),      // Attach the resource describing the application
+		sdkmetric.WithView(cfg.views...), // Apply configured views (buckets, renames, attribute filters)
+	)...)
+
+	// Register the optional Go runtime and process instruments under the same provider and resource
+	if cfg.runtimeMetrics {
+		if err := RegisterRuntimeMetrics(mp); err != nil {
+			return nil, shutdownOnError(mp, cfg, fmt.Errorf("failed to register runtime metrics: %w", err))
+		}
+	}
+	if cfg.processMetrics {
+		if err := RegisterProcessMetrics(mp); err != nil {
+			return nil, shutdownOnError(mp, cfg, fmt.Errorf("failed to register process metrics: %w", err))
+		}
+	}
+
+	// Populate the deprecated globals from an HTTPServerMetrics created on the new provider, only
+	// with the legacy instruments: stable ones are created by NewHTTPServerMetrics when needed
+	RequestCounter, RequestDuration = nil, nil
+	if cfg.semconv.EmitLegacy() {
+		httpMetrics, err := NewHTTPServerMetrics(mp, serviceName, requestCounterName, requestDurationName, WithSemconvMode(httpconv.ModeLegacy))
+		if err != nil {
+			return nil, shutdownOnError(mp, cfg, err)
+		}
+		RequestCounter = httpMetrics.RequestCounter
+		RequestDuration = httpMetrics.RequestDuration
+	}
+
 	// Set the global MeterProvider so it can be used throughout the application
 	otel.SetMeterProvider(mp)
 
-	// Create a Meter to define and record metrics
-	meter := mp.Meter(serviceName) // Use the service name as the meter name
+	// Return the MeterProvider for further use (e.g., shutting down or additional configuration)
+	return mp, nil
+}
 
-	// Define an Int64Counter to track the total number of HTTP requests
-	RequestCounter, err = meter.Int64Counter(
-		requestCounterName, // Use the provided metric name
-		metric.WithDescription("Total number of HTTP requests"), // Metric description
-	)
+// shutdownOnError shuts down mp, which InitMetrics failed to set up completely, bounded by the
+// export timeout, and returns err along with any shutdown error.
+func shutdownOnError(mp *sdkmetric.MeterProvider, cfg config, err error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	return errors.Join(err, mp.Shutdown(ctx))
+}
+
+// newOTLPExporter creates the OTLP/gRPC metric exporter with the connection settings from cfg.
+func newOTLPExporter(ctx context.Context, cfg config, endpoint string) (sdkmetric.Exporter, error) {
+	// TLS or plaintext connection, and the bearer token if any
+	transport, err := otlpconfig.NewGRPCTransport(cfg.tls, cfg.token)
 	if err != nil {
 		return nil, err
 	}
+	exporterOpts := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(endpoint),                   // Specify the OTLP endpoint
+		otlpmetricgrpc.WithHeaders(cfg.headers),                 // Extra headers such as API keys
+		otlpmetricgrpc.WithTemporalitySelector(cfg.temporality), // Delta or cumulative per instrument kind
+		otlpmetricgrpc.WithDialOption(transport.DialOptions...),
+	}
+	if transport.Credentials == nil {
+		exporterOpts = append(exporterOpts, otlpmetricgrpc.WithInsecure()) // Use insecure connection (no TLS)
+	} else {
+		exporterOpts = append(exporterOpts, otlpmetricgrpc.WithTLSCredentials(transport.Credentials))
+	}
 
-	// Define a Float64Histogram to track the duration of HTTP requests
-	RequestDuration, err = meter.Float64Histogram(
-		requestDurationName, // Use the provided metric name
-		metric.WithDescription("Histogram of response time for handler in seconds"), // Metric description
-	)
+	// Create OTLP metric exporter to send metrics to the specified endpoint
+	metricExporter, err := otlpmetricgrpc.New(ctx, exporterOpts...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
 	}
-
-	// Return the MeterProvider for further use (e.g., shutting down or additional configuration)
-	return mp, nil
+	return metricExporter, nil
 }
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"opentelemetry-api/internal/httpconv"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.uber.org/zap"
+)
+
+func TestInitMetricsGlobalInstrumentsFollowSemconv(t *testing.T) {
+	previous := otel.GetMeterProvider()
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	tests := []struct {
+		name        string
+		mode        httpconv.Mode
+		wantGlobals bool
+	}{
+		{"legacy", httpconv.ModeLegacy, true},
+		{"duplicate", httpconv.ModeDuplicate, true},
+		{"stable", httpconv.ModeStable, false},
+	}
+	for _, tt := range tests {
+		mp, err := InitMetrics("", "test", "requests_total", "request_duration_seconds", zap.NewNop(),
+			WithOTLP(false),
+			WithReader(sdkmetric.NewManualReader()),
+			WithHTTPSemconv(tt.mode),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := RequestCounter != nil && RequestDuration != nil; got != tt.wantGlobals {
+			t.Errorf("%s: legacy globals created = %v, want %v", tt.name, got, tt.wantGlobals)
+		}
+		_ = mp.Shutdown(context.Background())
+	}
+}
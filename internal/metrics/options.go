@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"time"
+
+	"opentelemetry-api/internal/httpconv"
+	"opentelemetry-api/internal/otlpconfig"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+)
+
+// config holds the optional settings applied by InitMetrics.
+type config struct {
+	tls      otlpconfig.TLSConfig  // Transport security for the OTLP exporter
+	headers  map[string]string     // Extra headers sent with every OTLP export
+	token    *otlpconfig.TokenFile // Bearer token attached to every OTLP export, nil when unset
+	resource *sdkresource.Resource // Resource describing the service, nil to detect one
+	views    []sdkmetric.View      // Views applied to every instrument
+	otlp     bool                  // Whether metrics are pushed over OTLP
+	readers  []sdkmetric.Reader    // Additional readers, e.g. from NewPrometheusReader
+
+	interval    time.Duration                 // Time between OTLP exports
+	timeout     time.Duration                 // Upper bound for a single OTLP export
+	temporality sdkmetric.TemporalitySelector // Temporality of the OTLP exported metrics
+
+	runtimeMetrics bool // Whether Go runtime metrics are registered
+	processMetrics bool // Whether process metrics are registered
+
+	semconv httpconv.Mode // HTTP semantic conventions of the deprecated global instruments
+}
+
+// newConfig returns the default configuration with opts applied on top.
+func newConfig(opts ...Option) config {
+	cfg := config{
+		tls:         otlpconfig.TLSConfig{Insecure: true},
+		otlp:        true,
+		interval:    60 * time.Second,
+		timeout:     30 * time.Second,
+		temporality: sdkmetric.DefaultTemporalitySelector,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// Option customizes the MeterProvider built by InitMetrics.
+type Option func(*config)
+
+// WithTLS configures transport security for the OTLP metric exporter.
+// Defaults to an insecure (plaintext) connection.
+func WithTLS(tls otlpconfig.TLSConfig) Option {
+	return func(c *config) {
+		c.tls = tls
+	}
+}
+
+// WithHeaders adds headers, e.g. parsed by otlpconfig.ParseHeaders, to every OTLP metric exporter call.
+func WithHeaders(headers map[string]string) Option {
+	return func(c *config) {
+		c.headers = headers
+	}
+}
+
+// WithTokenFile sends the token from token as an "authorization: Bearer" header with every
+// OTLP metric exporter call. Over gRPC the file is re-read when it changes.
+func WithTokenFile(token *otlpconfig.TokenFile) Option {
+	return func(c *config) {
+		c.token = token
+	}
+}
+
+// WithResource sets the resource the MeterProvider reports with every metric (it becomes
+// target_info on the Prometheus endpoint). Pass the resource shared with traces and logs to
+// keep the service attributes consistent. By default one is detected for the service name.
+func WithResource(res *sdkresource.Resource) Option {
+	return func(c *config) {
+		c.resource = res
+	}
+}
+
+// WithViews registers views, e.g. loaded by LoadViews, to customize bucket boundaries,
+// aggregations, attribute sets and names of the metric streams.
+func WithViews(views ...sdkmetric.View) Option {
+	return func(c *config) {
+		c.views = append(c.views, views...)
+	}
+}
+
+// WithOTLP enables or disables the OTLP periodic reader. Enabled by default; disable it when
+// metrics are only scraped through a reader registered with WithReader.
+func WithOTLP(enabled bool) Option {
+	return func(c *config) {
+		c.otlp = enabled
+	}
+}
+
+// WithReader registers an additional reader, such as the Prometheus reader returned by
+// NewPrometheusReader, next to the OTLP periodic reader.
+func WithReader(reader sdkmetric.Reader) Option {
+	return func(c *config) {
+		c.readers = append(c.readers, reader)
+	}
+}
+
+// WithExportInterval sets the time between two OTLP exports. Defaults to 60s.
+func WithExportInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval > 0 {
+			c.interval = interval
+		}
+	}
+}
+
+// WithExportTimeout bounds how long a single OTLP export may take. Defaults to 30s.
+func WithExportTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+// WithTemporality selects the temporality of the metrics exported over OTLP, e.g. a selector
+// built by NewTemporalitySelector for backends requiring delta. Defaults to cumulative for
+// every instrument kind. Pull readers such as the Prometheus one always use cumulative.
+func WithTemporality(selector sdkmetric.TemporalitySelector) Option {
+	return func(c *config) {
+		if selector != nil {
+			c.temporality = selector
+		}
+	}
+}
+
+// WithRuntimeMetrics enables the Go runtime metrics registered by RegisterRuntimeMetrics.
+// Disabled by default.
+func WithRuntimeMetrics(enabled bool) Option {
+	return func(c *config) {
+		c.runtimeMetrics = enabled
+	}
+}
+
+// WithProcessMetrics enables the process metrics registered by RegisterProcessMetrics.
+// Disabled by default.
+func WithProcessMetrics(enabled bool) Option {
+	return func(c *config) {
+		c.processMetrics = enabled
+	}
+}
+
+// WithHTTPSemconv selects the HTTP semantic conventions in use, so that the deprecated
+// RequestCounter and RequestDuration globals are only created, with their legacy names, when
+// mode emits the legacy conventions. Defaults to httpconv.ModeLegacy.
+func WithHTTPSemconv(mode httpconv.Mode) Option {
+	return func(c *config) {
+		c.semconv = mode
+	}
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// userHZ is the kernel's USER_HZ, the unit of the CPU times in /proc/<pid>/stat. It is fixed
+// at 100 on every mainstream Linux architecture.
+const userHZ = 100
+
+// processStart approximates the process start time for process.uptime.
+var processStart = time.Now()
+
+// RegisterProcessMetrics registers observable instruments reporting statistics of the current
+// process, read from /proc/self on every collection: CPU time, resident and virtual memory,
+// threads, open file descriptors and uptime. Names follow the OpenTelemetry process semantic
+// conventions.
+//
+// /proc is only available on Linux. Elsewhere, or when it cannot be read, the instruments are
+// registered but report nothing, except for process.uptime.
+//
+// Example usage:
+//
+//	if err := RegisterProcessMetrics(mp); err != nil {
+//	    logger.Error("failed to register process metrics", zap.Error(err))
+//	}
+func RegisterProcessMetrics(mp metric.MeterProvider) error {
+	meter := mp.Meter("opentelemetry-api/internal/metrics/process")
+
+	cpuTime, err := meter.Float64ObservableCounter("process.cpu.time",
+		metric.WithDescription("Total CPU seconds broken down by different CPU modes."), metric.WithUnit("s"))
+	if err != nil {
+		return err
+	}
+	memoryUsage, err := meter.Int64ObservableUpDownCounter("process.memory.usage",
+		metric.WithDescription("The amount of physical memory in use."), metric.WithUnit("By"))
+	if err != nil {
+		return err
+	}
+	memoryVirtual, err := meter.Int64ObservableUpDownCounter("process.memory.virtual",
+		metric.WithDescription("The amount of committed virtual memory."), metric.WithUnit("By"))
+	if err != nil {
+		return err
+	}
+	threads, err := meter.Int64ObservableUpDownCounter("process.thread.count",
+		metric.WithDescription("Process threads count."), metric.WithUnit("{thread}"))
+	if err != nil {
+		return err
+	}
+	openFDs, err := meter.Int64ObservableUpDownCounter("process.open_file_descriptor.count",
+		metric.WithDescription("Number of file descriptors in use by the process."), metric.WithUnit("{file_descriptor}"))
+	if err != nil {
+		return err
+	}
+	uptime, err := meter.Float64ObservableGauge("process.uptime",
+		metric.WithDescription("The time the process has been running."), metric.WithUnit("s"))
+	if err != nil {
+		return err
+	}
+
+	userMode := metric.WithAttributes(attribute.String("cpu.mode", "user"))
+	systemMode := metric.WithAttributes(attribute.String("cpu.mode", "system"))
+	pageSize := int64(os.Getpagesize())
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveFloat64(uptime, time.Since(processStart).Seconds())
+
+		if stat, err := readProcStat(); err == nil {
+			o.ObserveFloat64(cpuTime, float64(stat.utime)/userHZ, userMode)
+			o.ObserveFloat64(cpuTime, float64(stat.stime)/userHZ, systemMode)
+			o.ObserveInt64(memoryUsage, stat.rss*pageSize)
+			o.ObserveInt64(memoryVirtual, stat.vsize)
+			o.ObserveInt64(threads, stat.threads)
+		}
+		if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+			o.ObserveInt64(openFDs, int64(len(entries)))
+		}
+		return nil
+	}, cpuTime, memoryUsage, memoryVirtual, threads, openFDs, uptime)
+	return err
+}
+
+// procStat holds the fields of /proc/self/stat used by RegisterProcessMetrics.
+type procStat struct {
+	utime   int64 // User mode CPU time in clock ticks
+	stime   int64 // Kernel mode CPU time in clock ticks
+	threads int64 // Number of threads
+	vsize   int64 // Virtual memory size in bytes
+	rss     int64 // Resident set size in pages
+}
+
+// readProcStat parses /proc/self/stat (see proc(5)).
+func readProcStat() (procStat, error) {
+	data, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return procStat{}, err
+	}
+	// The command name (field 2) may contain spaces, so parse from the closing parenthesis
+	s := string(data)
+	i := strings.LastIndexByte(s, ')')
+	if i < 0 {
+		return procStat{}, fmt.Errorf("malformed /proc/self/stat")
+	}
+	// fields[0] is field 3 (state)
+	fields := strings.Fields(s[i+1:])
+	if len(fields) < 22 {
+		return procStat{}, fmt.Errorf("malformed /proc/self/stat: %d fields", len(fields)+2)
+	}
+
+	var stat procStat
+	for _, f := range []struct {
+		dst   *int64
+		field int
+	}{
+		{&stat.utime, 14},
+		{&stat.stime, 15},
+		{&stat.threads, 20},
+		{&stat.vsize, 23},
+		{&stat.rss, 24},
+	} {
+		if *f.dst, err = strconv.ParseInt(fields[f.field-3], 10, 64); err != nil {
+			return procStat{}, fmt.Errorf("malformed /proc/self/stat field %d: %w", f.field, err)
+		}
+	}
+	return stat, nil
+}
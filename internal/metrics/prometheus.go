@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.uber.org/zap"
+)
+
+// ExporterKind identifies how metrics leave the process.
+type ExporterKind string
+
+const (
+	ExporterOTLP       ExporterKind = "otlp"       // Pushed to the collector over OTLP/gRPC (default)
+	ExporterPrometheus ExporterKind = "prometheus" // Pulled by Prometheus from the admin listener
+)
+
+// ParseExporters maps the standard OTEL_METRICS_EXPORTER value, a comma separated list such as
+// "otlp,prometheus", to the exporters to enable. An empty value means "otlp" and "none"
+// returns an empty, non-nil list, disabling every exporter.
+//
+// Example usage:
+//
+//	kinds, err := ParseExporters(os.Getenv("OTEL_METRICS_EXPORTER"))
+//	if err != nil {
+//	    log.Fatalf("invalid metrics exporter configuration: %v", err)
+//	}
+func ParseExporters(value string) ([]ExporterKind, error) {
+	if strings.TrimSpace(value) == "" {
+		return []ExporterKind{ExporterOTLP}, nil
+	}
+
+	var kinds []ExporterKind
+	for _, name := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "otlp":
+			kinds = append(kinds, ExporterOTLP)
+		case "prometheus":
+			kinds = append(kinds, ExporterPrometheus)
+		case "none":
+			if len(strings.Split(value, ",")) > 1 {
+				return nil, fmt.Errorf("metrics exporter \"none\" cannot be combined with other exporters")
+			}
+			return []ExporterKind{}, nil
+		default:
+			return nil, fmt.Errorf("unsupported metrics exporter %q (expected one of otlp, prometheus, none)", name)
+		}
+	}
+	return kinds, nil
+}
+
+// NewPrometheusReader creates a pull based reader exposing the metrics of the MeterProvider it is
+// registered with in the Prometheus text format. Each call uses its own registry, so the handler
+// only serves OpenTelemetry metrics and several providers do not collide.
+//
+// Returns:
+//   - sdkmetric.Reader: The reader to register with WithReader.
+//   - http.Handler: The handler serving the scrape endpoint (usually mounted on /metrics).
+//   - error: An error if the exporter cannot be registered.
+//
+// Example usage:
+//
+//	reader, handler, err := NewPrometheusReader()
+//	if err != nil {
+//	    logger.Fatal("failed to create Prometheus reader", zap.Error(err))
+//	}
+//	mp, err := InitMetrics(endpoint, "my-api", counterName, durationName, logger, WithReader(reader))
+//	srv, err := ServeAdmin(":9464", handler, logger)
+func NewPrometheusReader() (sdkmetric.Reader, http.Handler, error) {
+	registry := prometheus.NewRegistry()
+	exporter, err := otelprom.New(otelprom.WithRegisterer(registry))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Prometheus exporter: %w", err)
+	}
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError, // Serve the metrics that could be gathered
+	})
+	return exporter, handler, nil
+}
+
+// ServeAdmin starts an HTTP listener on addr serving handler on /metrics. It is meant to be
+// bound to an internal port, separate from the application listener, so that scrapes bypass
+// the application middleware and are not exposed publicly.
+//
+// The listener is opened before ServeAdmin returns, so an unavailable address is reported as an
+// error. Serving errors afterwards are logged. Stop the server with its Shutdown method.
+//
+// Example usage:
+//
+//	srv, err := ServeAdmin(":9464", handler, logger)
+//	if err != nil {
+//	    logger.Fatal("failed to start admin listener", zap.Error(err))
+//	}
+//	defer srv.Shutdown(context.Background())
+func ServeAdmin(addr string, handler http.Handler, logger *zap.Logger) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on admin address %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", handler)
+	srv := &http.Server{
+		Addr:              ln.Addr().String(),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		logger.Info("Starting admin server", zap.String("address", srv.Addr))
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("admin server error", zap.Error(err))
+		}
+	}()
+	return srv, nil
+}
@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	rtmetrics "runtime/metrics"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// runtime/metrics sample names read by RegisterRuntimeMetrics.
+const (
+	rtGoroutines   = "/sched/goroutines:goroutines"
+	rtMemoryTotal  = "/memory/classes/total:bytes"
+	rtMemoryFreed  = "/memory/classes/heap/released:bytes"
+	rtHeapObjects  = "/memory/classes/heap/objects:bytes"
+	rtMemoryLimit  = "/gc/gomemlimit:bytes"
+	rtAllocBytes   = "/gc/heap/allocs:bytes"
+	rtAllocObjects = "/gc/heap/allocs:objects"
+	rtHeapGoal     = "/gc/heap/goal:bytes"
+	rtGCCycles     = "/gc/cycles/total:gc-cycles"
+	rtGCPauses     = "/sched/pauses/total/gc:seconds"
+	rtGOMAXPROCS   = "/sched/gomaxprocs:threads"
+	rtGOGC         = "/gc/gogc:percent"
+)
+
+// RegisterRuntimeMetrics registers observable instruments reporting Go runtime statistics read
+// from runtime/metrics: goroutines, memory usage and limits, heap allocations, GC cycles and
+// pause time, GOMAXPROCS and GOGC. All values are read once per collection.
+//
+// The instruments follow the names of the OpenTelemetry Go runtime semantic conventions where
+// they exist (go.goroutine.count, go.memory.used, ...). GC pause time is estimated from the
+// runtime's pause histogram using the midpoint of each bucket.
+//
+// Example usage:
+//
+//	if err := RegisterRuntimeMetrics(mp); err != nil {
+//	    logger.Error("failed to register runtime metrics", zap.Error(err))
+//	}
+func RegisterRuntimeMetrics(mp metric.MeterProvider) error {
+	meter := mp.Meter("opentelemetry-api/internal/metrics/runtime")
+
+	goroutines, err := meter.Int64ObservableUpDownCounter("go.goroutine.count",
+		metric.WithDescription("Count of live goroutines."), metric.WithUnit("{goroutine}"))
+	if err != nil {
+		return err
+	}
+	memoryUsed, err := meter.Int64ObservableUpDownCounter("go.memory.used",
+		metric.WithDescription("Memory used by the Go runtime, excluding heap memory released to the OS."), metric.WithUnit("By"))
+	if err != nil {
+		return err
+	}
+	heapObjects, err := meter.Int64ObservableUpDownCounter("go.memory.heap",
+		metric.WithDescription("Memory occupied by live and not yet swept heap objects."), metric.WithUnit("By"))
+	if err != nil {
+		return err
+	}
+	memoryLimit, err := meter.Int64ObservableUpDownCounter("go.memory.limit",
+		metric.WithDescription("Go runtime memory limit configured by the user, if a limit exists."), metric.WithUnit("By"))
+	if err != nil {
+		return err
+	}
+	allocated, err := meter.Int64ObservableCounter("go.memory.allocated",
+		metric.WithDescription("Memory allocated to the heap by the application."), metric.WithUnit("By"))
+	if err != nil {
+		return err
+	}
+	allocations, err := meter.Int64ObservableCounter("go.memory.allocations",
+		metric.WithDescription("Count of allocations to the heap by the application."), metric.WithUnit("{allocation}"))
+	if err != nil {
+		return err
+	}
+	gcGoal, err := meter.Int64ObservableUpDownCounter("go.memory.gc.goal",
+		metric.WithDescription("Heap size target for the end of the GC cycle."), metric.WithUnit("By"))
+	if err != nil {
+		return err
+	}
+	gcCycles, err := meter.Int64ObservableCounter("go.gc.count",
+		metric.WithDescription("Count of completed GC cycles."), metric.WithUnit("{gc_cycle}"))
+	if err != nil {
+		return err
+	}
+	gcPause, err := meter.Float64ObservableCounter("go.gc.pause.time",
+		metric.WithDescription("Estimated total time the world was stopped for garbage collection."), metric.WithUnit("s"))
+	if err != nil {
+		return err
+	}
+	maxProcs, err := meter.Int64ObservableUpDownCounter("go.processor.limit",
+		metric.WithDescription("The number of OS threads that can execute user-level Go code simultaneously."), metric.WithUnit("{thread}"))
+	if err != nil {
+		return err
+	}
+	gogc, err := meter.Int64ObservableUpDownCounter("go.config.gogc",
+		metric.WithDescription("Heap size target percentage configured by the user, otherwise 100."), metric.WithUnit("%"))
+	if err != nil {
+		return err
+	}
+
+	samples := []rtmetrics.Sample{
+		{Name: rtGoroutines}, {Name: rtMemoryTotal}, {Name: rtMemoryFreed}, {Name: rtHeapObjects},
+		{Name: rtMemoryLimit}, {Name: rtAllocBytes}, {Name: rtAllocObjects}, {Name: rtHeapGoal},
+		{Name: rtGCCycles}, {Name: rtGCPauses}, {Name: rtGOMAXPROCS}, {Name: rtGOGC},
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		// Each reader (OTLP, Prometheus) may collect concurrently, so read into a private copy
+		read := make([]rtmetrics.Sample, len(samples))
+		copy(read, samples)
+		rtmetrics.Read(read)
+
+		values := make(map[string]rtmetrics.Value, len(read))
+		for _, s := range read {
+			values[s.Name] = s.Value
+		}
+		observeUint64 := func(inst metric.Int64Observable, name string) {
+			if v := values[name]; v.Kind() == rtmetrics.KindUint64 {
+				o.ObserveInt64(inst, clampInt64(v.Uint64()))
+			}
+		}
+
+		observeUint64(goroutines, rtGoroutines)
+		if total, freed := values[rtMemoryTotal], values[rtMemoryFreed]; total.Kind() == rtmetrics.KindUint64 && freed.Kind() == rtmetrics.KindUint64 {
+			o.ObserveInt64(memoryUsed, clampInt64(total.Uint64()-freed.Uint64()))
+		}
+		observeUint64(heapObjects, rtHeapObjects)
+		// math.MaxInt64 means no limit is configured
+		if v := values[rtMemoryLimit]; v.Kind() == rtmetrics.KindUint64 && v.Uint64() != math.MaxInt64 {
+			o.ObserveInt64(memoryLimit, clampInt64(v.Uint64()))
+		}
+		observeUint64(allocated, rtAllocBytes)
+		observeUint64(allocations, rtAllocObjects)
+		observeUint64(gcGoal, rtHeapGoal)
+		observeUint64(gcCycles, rtGCCycles)
+		if v := values[rtGCPauses]; v.Kind() == rtmetrics.KindFloat64Histogram {
+			o.ObserveFloat64(gcPause, histogramSum(v.Float64Histogram()))
+		}
+		observeUint64(maxProcs, rtGOMAXPROCS)
+		observeUint64(gogc, rtGOGC)
+		return nil
+	}, goroutines, memoryUsed, heapObjects, memoryLimit, allocated, allocations, gcGoal, gcCycles, gcPause, maxProcs, gogc)
+	return err
+}
+
+// histogramSum estimates the sum of the values recorded in a runtime/metrics histogram using the
+// midpoint of every bucket, or its finite bound for the open-ended first and last buckets.
+func histogramSum(h *rtmetrics.Float64Histogram) float64 {
+	var sum float64
+	for i, count := range h.Counts {
+		if count == 0 {
+			continue
+		}
+		lo, hi := h.Buckets[i], h.Buckets[i+1]
+		var mid float64
+		switch {
+		case math.IsInf(lo, -1):
+			mid = hi
+		case math.IsInf(hi, 1):
+			mid = lo
+		default:
+			mid = lo + (hi-lo)/2
+		}
+		sum += mid * float64(count)
+	}
+	return sum
+}
+
+// clampInt64 converts a uint64 runtime value to int64, saturating on overflow.
+func clampInt64(v uint64) int64 {
+	if v > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(v)
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// instrumentKinds maps the names accepted in temporality overrides to instrument kinds.
+var instrumentKinds = map[string]sdkmetric.InstrumentKind{
+	"counter":                    sdkmetric.InstrumentKindCounter,
+	"up_down_counter":            sdkmetric.InstrumentKindUpDownCounter,
+	"histogram":                  sdkmetric.InstrumentKindHistogram,
+	"gauge":                      sdkmetric.InstrumentKindGauge,
+	"observable_counter":         sdkmetric.InstrumentKindObservableCounter,
+	"observable_up_down_counter": sdkmetric.InstrumentKindObservableUpDownCounter,
+	"observable_gauge":           sdkmetric.InstrumentKindObservableGauge,
+}
+
+// NewTemporalitySelector builds the temporality selector used by the OTLP metric exporter.
+//
+// Parameters:
+//   - preference: The standard OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE value:
+//     "cumulative" (default when empty), "delta" (delta for counters and histograms, including
+//     observable counters) or "lowmemory" (delta for synchronous counters and histograms only).
+//     Up-down counters and gauges stay cumulative with every preference.
+//   - overrides: Per instrument kind overrides applied on top of the preference, as a comma
+//     separated list of kind=temporality pairs, e.g. "histogram=cumulative,observable_counter=delta".
+//     Kinds are counter, up_down_counter, histogram, gauge, observable_counter,
+//     observable_up_down_counter and observable_gauge.
+//
+// Example usage:
+//
+//	selector, err := NewTemporalitySelector("delta", "histogram=cumulative")
+//	if err != nil {
+//	    logger.Fatal("invalid temporality configuration", zap.Error(err))
+//	}
+//	mp, err := InitMetrics(endpoint, "my-api", counterName, durationName, logger, WithTemporality(selector))
+func NewTemporalitySelector(preference, overrides string) (sdkmetric.TemporalitySelector, error) {
+	temporalities := make(map[sdkmetric.InstrumentKind]metricdata.Temporality, len(instrumentKinds))
+	for _, kind := range instrumentKinds {
+		temporalities[kind] = metricdata.CumulativeTemporality
+	}
+
+	switch strings.ToLower(strings.TrimSpace(preference)) {
+	case "", "cumulative":
+	case "delta":
+		temporalities[sdkmetric.InstrumentKindCounter] = metricdata.DeltaTemporality
+		temporalities[sdkmetric.InstrumentKindHistogram] = metricdata.DeltaTemporality
+		temporalities[sdkmetric.InstrumentKindObservableCounter] = metricdata.DeltaTemporality
+	case "lowmemory":
+		temporalities[sdkmetric.InstrumentKindCounter] = metricdata.DeltaTemporality
+		temporalities[sdkmetric.InstrumentKindHistogram] = metricdata.DeltaTemporality
+	default:
+		return nil, fmt.Errorf("unsupported temporality preference %q (expected cumulative, delta or lowmemory)", preference)
+	}
+
+	for _, pair := range strings.Split(overrides, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid temporality override %q (expected kind=temporality)", pair)
+		}
+		kind, ok := instrumentKinds[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown instrument kind %q in temporality override", name)
+		}
+		temporality, err := parseTemporality(value)
+		if err != nil {
+			return nil, err
+		}
+		temporalities[kind] = temporality
+	}
+
+	return func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+		if temporality, ok := temporalities[kind]; ok {
+			return temporality
+		}
+		return metricdata.CumulativeTemporality
+	}, nil
+}
+
+// parseTemporality parses "delta" or "cumulative".
+func parseTemporality(s string) (metricdata.Temporality, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "delta":
+		return metricdata.DeltaTemporality, nil
+	case "cumulative":
+		return metricdata.CumulativeTemporality, nil
+	default:
+		return 0, fmt.Errorf("unsupported temporality %q (expected delta or cumulative)", s)
+	}
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// ViewConfig describes one sdkmetric.View in a views file.
+//
+// Example views file:
+//
+//	{"views": [
+//	  {"instrument": "http_request_duration_seconds",
+//	   "aggregation": {"type": "explicit_bucket_histogram", "boundaries": [0.01, 0.05, 0.1, 0.5, 1, 5]}},
+//	  {"instrument": "http.server.request.duration",
+//	   "aggregation": {"type": "base2_exponential_histogram", "max_size": 160, "max_scale": 20}},
+//	  {"instrument": "http_requests_total", "rename": "requests_total",
+//	   "attribute_keys": ["http.method", "http.path", "http.status_code"]}
+//	]}
+type ViewConfig struct {
+	Instrument    string             `json:"instrument"`               // Instrument name to match; "*" and "?" wildcards are supported
+	Meter         string             `json:"meter,omitempty"`          // Restrict the view to instruments of this meter
+	Rename        string             `json:"rename,omitempty"`         // New stream name; not allowed with wildcards
+	Description   string             `json:"description,omitempty"`    // New stream description
+	Aggregation   *AggregationConfig `json:"aggregation,omitempty"`    // Aggregation override
+	AttributeKeys []string           `json:"attribute_keys,omitempty"` // Allow-list of attribute keys; all other attributes are dropped
+}
+
+// AggregationConfig selects the aggregation of a view.
+type AggregationConfig struct {
+	// Type is one of "default", "drop", "sum", "last_value",
+	// "explicit_bucket_histogram" or "base2_exponential_histogram".
+	Type       string    `json:"type"`
+	Boundaries []float64 `json:"boundaries,omitempty"` // Bucket upper bounds for explicit_bucket_histogram
+	MaxSize    int32     `json:"max_size,omitempty"`   // Maximum bucket count for base2_exponential_histogram (default 160)
+	MaxScale   int32     `json:"max_scale,omitempty"`  // Maximum scale for base2_exponential_histogram (default 20)
+	NoMinMax   bool      `json:"no_min_max,omitempty"` // Do not record min and max for histograms
+}
+
+// viewsFile is the on-disk layout of a views file.
+type viewsFile struct {
+	Views []ViewConfig `json:"views"`
+}
+
+// LoadViews reads a JSON views file and builds the views it describes.
+//
+// Example usage:
+//
+//	views, err := LoadViews("/etc/my-app/views.json")
+//	if err != nil {
+//	    logger.Fatal("invalid metric views", zap.Error(err))
+//	}
+//	mp, err := InitMetrics(endpoint, "my-api", counterName, durationName, logger, WithViews(views...))
+func LoadViews(path string) ([]sdkmetric.View, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metric views file: %w", err)
+	}
+	var f viewsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse metric views file %s: %w", path, err)
+	}
+
+	views := make([]sdkmetric.View, 0, len(f.Views))
+	for i, vc := range f.Views {
+		view, err := vc.View()
+		if err != nil {
+			return nil, fmt.Errorf("invalid view #%d in %s: %w", i, path, err)
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// View builds the sdkmetric.View described by the configuration.
+func (c ViewConfig) View() (sdkmetric.View, error) {
+	if c.Instrument == "" {
+		return nil, fmt.Errorf("view is missing an instrument name")
+	}
+	if c.Rename != "" && strings.ContainsAny(c.Instrument, "*?") {
+		return nil, fmt.Errorf("cannot rename instruments matched by wildcard %q", c.Instrument)
+	}
+
+	criteria := sdkmetric.Instrument{Name: c.Instrument}
+	if c.Meter != "" {
+		criteria.Scope.Name = c.Meter
+	}
+
+	mask := sdkmetric.Stream{
+		Name:        c.Rename,
+		Description: c.Description,
+	}
+	if c.Aggregation != nil {
+		agg, err := c.Aggregation.aggregation()
+		if err != nil {
+			return nil, err
+		}
+		mask.Aggregation = agg
+	}
+	if len(c.AttributeKeys) > 0 {
+		keys := make([]attribute.Key, len(c.AttributeKeys))
+		for i, k := range c.AttributeKeys {
+			keys[i] = attribute.Key(k)
+		}
+		mask.AttributeFilter = attribute.NewAllowKeysFilter(keys...)
+	}
+
+	return sdkmetric.NewView(criteria, mask), nil
+}
+
+// aggregation maps the configuration to an sdkmetric.Aggregation.
+func (c AggregationConfig) aggregation() (sdkmetric.Aggregation, error) {
+	switch strings.ToLower(c.Type) {
+	case "", "default":
+		return sdkmetric.AggregationDefault{}, nil
+	case "drop":
+		return sdkmetric.AggregationDrop{}, nil
+	case "sum":
+		return sdkmetric.AggregationSum{}, nil
+	case "last_value":
+		return sdkmetric.AggregationLastValue{}, nil
+	case "explicit_bucket_histogram":
+		for i := 1; i < len(c.Boundaries); i++ {
+			if c.Boundaries[i] <= c.Boundaries[i-1] {
+				return nil, fmt.Errorf("histogram boundaries must be strictly increasing")
+			}
+		}
+		return sdkmetric.AggregationExplicitBucketHistogram{
+			Boundaries: c.Boundaries,
+			NoMinMax:   c.NoMinMax,
+		}, nil
+	case "base2_exponential_histogram":
+		agg := sdkmetric.AggregationBase2ExponentialHistogram{
+			MaxSize:  c.MaxSize,
+			MaxScale: c.MaxScale,
+			NoMinMax: c.NoMinMax,
+		}
+		if agg.MaxSize == 0 {
+			agg.MaxSize = 160
+		}
+		if agg.MaxScale == 0 {
+			agg.MaxScale = 20
+		}
+		if agg.MaxScale < -10 || agg.MaxScale > 20 {
+			return nil, fmt.Errorf("exponential histogram max_scale %d is outside [-10, 20]", agg.MaxScale)
+		}
+		return agg, nil
+	default:
+		return nil, fmt.Errorf("unsupported aggregation type %q", c.Type)
+	}
+}
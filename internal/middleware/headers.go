@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"opentelemetry-api/internal/redact"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// Prefixes of the captured header span attributes and log fields, followed by the lower-cased
+// header name, as defined by the HTTP semantic conventions.
+const (
+	requestHeaderPrefix  = "http.request.header."
+	responseHeaderPrefix = "http.response.header."
+)
+
+// defaultHeaderRedactor redacts captured headers when no redactor is configured, so that
+// credentials such as Authorization or Cookie are never captured by default.
+var defaultHeaderRedactor = redact.Default()
+
+// capturedHeader is a header value selected by an allow-list, already redacted.
+type capturedHeader struct {
+	key    string   // Attribute or field name, e.g. "http.request.header.content-type"
+	values []string // Every value of the header
+}
+
+// captureHeaders returns the headers of h named in allowList, redacted with redactor (or
+// defaultHeaderRedactor when nil). Headers that are not present are skipped.
+func captureHeaders(prefix string, h http.Header, allowList []string, redactor *redact.Redactor) []capturedHeader {
+	if len(allowList) == 0 {
+		return nil
+	}
+	if redactor == nil {
+		redactor = defaultHeaderRedactor
+	}
+
+	captured := make([]capturedHeader, 0, len(allowList))
+	for _, name := range allowList {
+		values := h.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		redacted := make([]string, len(values))
+		for i, value := range values {
+			redacted[i] = redactor.Header(name, value)
+		}
+		captured = append(captured, capturedHeader{key: prefix + strings.ToLower(name), values: redacted})
+	}
+	return captured
+}
+
+// headerAttributes converts captured headers to span attributes.
+func headerAttributes(headers []capturedHeader) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, len(headers))
+	for i, h := range headers {
+		attrs[i] = attribute.StringSlice(h.key, h.values)
+	}
+	return attrs
+}
+
+// headerFields converts captured headers to log fields.
+func headerFields(headers []capturedHeader) []zap.Field {
+	fields := make([]zap.Field, len(headers))
+	for i, h := range headers {
+		fields[i] = zap.Strings(h.key, h.values)
+	}
+	return fields
+}
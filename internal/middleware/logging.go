@@ -6,6 +6,9 @@ import (
 	"sync"
 	"time"
 
+	"opentelemetry-api/internal/logging"
+	"opentelemetry-api/internal/redact"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
@@ -17,10 +20,46 @@ type contextKey string
 
 const (
 	LogLevelKey contextKey = "log_level"
+
+	// loggerKey stores the logger passed to LoggingMiddleware, retrieved by LoggerFromContext
+	loggerKey contextKey = "logger"
 )
 
+// loggingConfig holds the optional settings applied by LoggingMiddleware.
+type loggingConfig struct {
+	redactor        *redact.Redactor // Redacts the logged URL and headers, nil to log the URL as is
+	requestHeaders  []string         // Request headers logged as http.request.header.* fields
+	responseHeaders []string         // Response headers logged as http.response.header.* fields
+}
+
+// LoggingOption customizes LoggingMiddleware.
+type LoggingOption func(*loggingConfig)
+
+// WithLoggingRedactor redacts sensitive query parameters (e.g. ?token=...) from the logged URL
+// and sensitive values from the logged headers.
+func WithLoggingRedactor(redactor *redact.Redactor) LoggingOption {
+	return func(c *loggingConfig) {
+		c.redactor = redactor
+	}
+}
+
+// WithLoggingHeaders logs the listed request and response headers as http.request.header.<name>
+// and http.response.header.<name> fields. Values of sensitive headers (e.g. Authorization,
+// Cookie) are redacted, by default with redact.DefaultRules.
+func WithLoggingHeaders(request, response []string) LoggingOption {
+	return func(c *loggingConfig) {
+		c.requestHeaders = request
+		c.responseHeaders = response
+	}
+}
+
 // LoggingMiddleware logs details about each HTTP request using Zap.
-func LoggingMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
+func LoggingMiddleware(logger *zap.Logger, opts ...LoggingOption) func(http.Handler) http.Handler {
+	var cfg loggingConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
@@ -28,6 +67,9 @@ func LoggingMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 			// Wrap the response writer to capture status code and size
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
+			// Make the logger available to handlers through LoggerFromContext
+			r = r.WithContext(context.WithValue(r.Context(), loggerKey, logger))
+
 			// Call the next handler in the chain
 			next.ServeHTTP(ww, r)
 
@@ -45,9 +87,10 @@ func LoggingMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 			requestID := middleware.GetReqID(r.Context())
 			// Create a logger with attributes from the LoggingContext
 			logFields := []zap.Field{
+				logging.Context(r.Context()),        // Correlate the entry with the request span
 				zap.String("request_id", requestID), // Add the Request ID to the log fields
 				zap.String("method", r.Method),
-				zap.String("url", r.URL.String()),
+				zap.String("url", cfg.redactor.URL(r.URL.String())),
 				zap.String("path", routePattern),
 				zap.Int("status", ww.Status()),
 				zap.Int("size", ww.BytesWritten()),
@@ -55,6 +98,9 @@ func LoggingMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 				zap.String("remote_addr", r.RemoteAddr),
 				zap.String("user_agent", r.UserAgent()),
 			}
+			// Add the allow-listed request and response headers
+			logFields = append(logFields, headerFields(captureHeaders(requestHeaderPrefix, r.Header, cfg.requestHeaders, cfg.redactor))...)
+			logFields = append(logFields, headerFields(captureHeaders(responseHeaderPrefix, ww.Header(), cfg.responseHeaders, cfg.redactor))...)
 			// Retrieve or initialize the LoggingContext
 			loggingContext := GetLoggingContext(r.Context())
 
@@ -71,23 +117,12 @@ func LoggingMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 				logger.Warn("LoggingContext not found in request context", zap.String("request_id", requestID))
 			}
 
-			// Extract log level from the context (default to "info")
-			logLevel := r.Context().Value(LogLevelKey)
-			if logLevel == nil {
-				logLevel = zap.InfoLevel
-			}
-
 			// Create the logger with all fields
 			log := logger.With(logFields...)
 
-			// Log at the appropriate level
-			switch logLevel {
-			case zap.ErrorLevel:
-				log.Error("HTTP Request")
-			case zap.WarnLevel:
-				log.Warn("HTTP Request")
-			default:
-				log.Info("HTTP Request")
+			// Log at the level selected by the handler, escalated according to the response status
+			if ce := log.Check(requestLogLevel(r.Context(), loggingContext, ww.Status()), "HTTP Request"); ce != nil {
+				ce.Write()
 			}
 		})
 	}
@@ -96,15 +131,81 @@ func LoggingMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 // LoggingContextKey is the key used to store the LoggingContext in the request context.
 const LoggingContextKey contextKey = "logging_context"
 
-// WithLogLevel adds a log level to the request context.
+// WithLogLevel selects the level of the access log entry written by LoggingMiddleware.
+//
+// The level is stored in the request's LoggingContext, so it takes effect even if the returned
+// context is discarded. The returned context additionally carries the level under LogLevelKey
+// for requests without a LoggingContext. Prefer calling SetLevel on the LoggingContext directly.
 func WithLogLevel(ctx context.Context, level zapcore.Level) context.Context {
+	GetLoggingContext(ctx).SetLevel(level)
 	return context.WithValue(ctx, LogLevelKey, level)
 }
 
-// LoggingContext holds custom attributes for logging using sync.Map.
+// requestLogLevel returns the level of the access log entry: the level set on the
+// LoggingContext, or under LogLevelKey in ctx, defaulting to Info. It is escalated to Warn for
+// 4xx and to Error for 5xx responses, and capped at Error so that an access log entry never
+// panics or exits the process.
+func requestLogLevel(ctx context.Context, loggingContext *LoggingContext, status int) zapcore.Level {
+	level := zapcore.InfoLevel
+	if selected, ok := loggingContext.Level(); ok {
+		level = selected
+	} else if selected, ok := ctx.Value(LogLevelKey).(zapcore.Level); ok {
+		level = selected
+	}
+
+	switch {
+	case status >= 500 && level < zapcore.ErrorLevel:
+		level = zapcore.ErrorLevel
+	case status >= 400 && level < zapcore.WarnLevel:
+		level = zapcore.WarnLevel
+	}
+	if level > zapcore.ErrorLevel {
+		level = zapcore.ErrorLevel
+	}
+	return level
+}
+
+// LoggerFromContext returns the logger passed to LoggingMiddleware, enriched with the request's
+// correlation fields: trace_id and span_id of the span active in ctx, request_id, and the
+// attributes currently stored in the LoggingContext. Pass the context of a child span started in
+// the handler to correlate the entries with that span. Outside of LoggingMiddleware the global
+// zap logger (zap.L()) is enriched instead: install the application logger with
+// zap.ReplaceGlobals, as zap.L() discards every entry by default.
+//
+// Example usage:
+//
+//	func HelloHandler(w http.ResponseWriter, r *http.Request) {
+//		ctx, span := otel.Tracer("my-api").Start(r.Context(), "Handle /hello")
+//		defer span.End()
+//		middleware.LoggerFromContext(ctx).Info("looking up user", zap.String("user_id", id))
+//	}
+func LoggerFromContext(ctx context.Context) *zap.Logger {
+	logger, ok := ctx.Value(loggerKey).(*zap.Logger)
+	if !ok || logger == nil {
+		logger = zap.L()
+	}
+
+	fields := []zap.Field{logging.Context(ctx)} // Resolved to trace_id and span_id by the logger cores
+	if requestID := middleware.GetReqID(ctx); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+	if loggingContext := GetLoggingContext(ctx); loggingContext != nil {
+		loggingContext.IterateAttributes(func(key, value interface{}) {
+			// The trace fields always describe the span active in ctx, never a stored one
+			if keyStr, ok := key.(string); ok && keyStr != "trace_id" && keyStr != "span_id" {
+				fields = append(fields, zap.Any(keyStr, value))
+			}
+		})
+	}
+	return logger.With(fields...)
+}
+
+// LoggingContext holds custom attributes and the access log level of a request.
 type LoggingContext struct {
 	mu         sync.RWMutex           // Read-Write Mutex
 	attributes map[string]interface{} // Standard Go map
+	level      zapcore.Level          // Access log level, only meaningful when levelSet
+	levelSet   bool                   // Whether a handler selected a level
 }
 
 // newLoggingContext creates an initialized LoggingContext.
@@ -126,6 +227,29 @@ func (lc *LoggingContext) AddAttribute(key string, value interface{}) {
 	lc.attributes[key] = value
 }
 
+// SetLevel selects the level of the access log entry written by LoggingMiddleware for this
+// request. Any zap level can be used, e.g. zapcore.DebugLevel to demote noisy endpoints.
+// Calling SetLevel on a nil LoggingContext is a no-op. (Write operation)
+func (lc *LoggingContext) SetLevel(level zapcore.Level) {
+	if lc == nil {
+		return
+	}
+	lc.mu.Lock()         // Acquire exclusive write lock
+	defer lc.mu.Unlock() // Ensure lock is released
+	lc.level = level
+	lc.levelSet = true
+}
+
+// Level returns the level selected with SetLevel, and whether one was selected. (Read operation)
+func (lc *LoggingContext) Level() (zapcore.Level, bool) {
+	if lc == nil {
+		return zapcore.InfoLevel, false
+	}
+	lc.mu.RLock()         // Acquire shared read lock
+	defer lc.mu.RUnlock() // Ensure lock is released
+	return lc.level, lc.levelSet
+}
+
 // GetAttribute retrieves a custom attribute from the LoggingContext. (Read operation)
 // Note: Less commonly needed if IterateAttributes is the primary read path.
 func (lc *LoggingContext) GetAttribute(key string) (interface{}, bool) {
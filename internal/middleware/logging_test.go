@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggerFromContextFallsBackToGlobalLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	t.Cleanup(zap.ReplaceGlobals(zap.New(core)))
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-1")
+	LoggerFromContext(ctx).Info("outside of a request")
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("global logger got %d entries, want 1", len(entries))
+	}
+	if got := entries[0].ContextMap()["request_id"]; got != "req-1" {
+		t.Errorf("request_id = %v, want req-1", got)
+	}
+}
+
+func TestLoggerFromContextUsesMiddlewareLogger(t *testing.T) {
+	global, globalLogs := observer.New(zapcore.InfoLevel)
+	t.Cleanup(zap.ReplaceGlobals(zap.New(global)))
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	handler := InitializeLoggingContext(LoggingMiddleware(zap.New(core))(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		GetLoggingContext(r.Context()).AddAttribute("user_role", "admin")
+		LoggerFromContext(r.Context()).Info("in a request")
+	})))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	if globalLogs.Len() != 0 {
+		t.Errorf("global logger got %d entries, want none", globalLogs.Len())
+	}
+	found := logs.FilterMessage("in a request").All()
+	if len(found) != 1 {
+		t.Fatalf("middleware logger got %d handler entries, want 1", len(found))
+	}
+	if got := found[0].ContextMap()["user_role"]; got != "admin" {
+		t.Errorf("user_role = %v, want admin", got)
+	}
+}
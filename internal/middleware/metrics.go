@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"context"
-	"fmt"
+	"io"
 	"net/http"
+	"opentelemetry-api/internal/httpconv"
+	"opentelemetry-api/internal/metrics"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -36,14 +39,15 @@ func newMetricContext() *MetricContext {
 }
 
 // MetricsMiddleware is an HTTP middleware that collects and records metrics for incoming HTTP requests.
-// It tracks the number of requests and the duration of each request, providing valuable observability
+// It tracks the number of requests, the duration of each request, the number of requests in flight and
+// the request and response body sizes, providing valuable observability
 // for your application. This middleware is particularly useful for monitoring endpoints that may have
 // variable performance characteristics, such as those with dynamic paths (e.g., "/{id}") which can
 // generate a high cardinality of metrics if not handled carefully.
 //
 // Parameters:
-// - counter: An Int64Counter metric used to count the number of incoming HTTP requests.
-// - histogram: A Float64Histogram metric used to record the duration of HTTP requests in seconds.
+// - httpMetrics: The HTTPServerMetrics instrument set, created with metrics.NewHTTPServerMetrics.
+// - logger: A zap.Logger instance for logging errors and information.
 //
 // Returns:
 // - A middleware function that wraps an http.Handler to collect metrics.
@@ -51,15 +55,14 @@ func newMetricContext() *MetricContext {
 // Example Usage:
 //
 //	import (
-//		"go.opentelemetry.io/otel/metric"
-//		"go.opentelemetry.io/otel/attribute"
 //		"net/http"
+//		"opentelemetry-api/internal/metrics"
+//		sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 //	)
 //
 //	func main() {
-//		meter := metric.NewMeterProvider().Meter("example")
-//		counter := metric.Must(meter).NewInt64Counter("http_requests_total")
-//		histogram := metric.Must(meter).NewFloat64Histogram("http_request_duration_seconds")
+//		mp := sdkmetric.NewMeterProvider()
+//		httpMetrics, _ := metrics.NewHTTPServerMetrics(mp, "example", "http_requests_total", "http_request_duration_seconds")
 //
 //		mux := http.NewServeMux()
 //		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -67,7 +70,7 @@ func newMetricContext() *MetricContext {
 //		})
 //
 //		// Wrap the mux with the MetricsMiddleware
-//		handler := MetricsMiddleware(counter, histogram)(mux)
+//		handler := MetricsMiddleware(httpMetrics, logger)(mux)
 //
 //		http.ListenAndServe(":8080", handler)
 //	}
@@ -78,47 +81,114 @@ func newMetricContext() *MetricContext {
 // used carefully. For example, instead of including dynamic path segments (e.g., "/{id}") directly
 // in the metrics, you can use attributes like "method" or other static labels to keep the metrics
 // manageable and meaningful.
-func MetricsMiddleware(counter metric.Int64Counter, histogram metric.Float64Histogram, logger *zap.Logger) func(http.Handler) http.Handler {
+func MetricsMiddleware(httpMetrics *metrics.HTTPServerMetrics, logger *zap.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			mode := httpMetrics.Mode
+			scheme := requestScheme(r)
+
+			// Track the request as in flight, keyed by the route it is about to be dispatched to
+			activeRoute := resolveRoutePattern(r)
+			if mode.EmitLegacy() {
+				activeAttrs := metric.WithAttributes(httpconv.LegacyRequestAttributes(r.Method, httpconv.RouteOrPath(activeRoute, r.URL.Path))...)
+				httpMetrics.ActiveRequests.Add(r.Context(), 1, activeAttrs)
+				defer httpMetrics.ActiveRequests.Add(r.Context(), -1, activeAttrs)
+			}
+			if mode.EmitStable() {
+				activeAttrs := metric.WithAttributes(httpconv.StableRequestAttributes(r.Method, activeRoute, scheme)...)
+				httpMetrics.ServerActiveRequests.Add(r.Context(), 1, activeAttrs)
+				defer httpMetrics.ServerActiveRequests.Add(r.Context(), -1, activeAttrs)
+			}
+
+			// Count the bytes the handler reads from the request body
+			body := &countingReadCloser{ReadCloser: r.Body}
+			if r.Body != nil {
+				r.Body = body
+			}
 
 			// Call the next handler in the chain
 			next.ServeHTTP(ww, r)
 
-			// Extract the normalized route pattern from the Chi router
+			// Extract the normalized route pattern from the Chi router, empty if no route matched
 			routePattern := chi.RouteContext(r.Context()).RoutePattern()
-			if routePattern == "" {
-				// Fallback to the raw path if no route pattern is found
-				routePattern = r.URL.Path
-			}
 
 			// Calculate the duration of the request
 			duration := time.Since(start).Seconds()
 
-			// Retrieve custom attributes from the request context
-			customAttrs := GetMetricAttributes(r.Context())
+			// Retrieve custom attributes from the request context, redacting them and bounding their cardinality
+			customAttrs := httpMetrics.Redactor.Attributes(GetMetricAttributes(r.Context()))
+			customAttrs = httpMetrics.CustomAttributes.Limit(r.Context(), customAttrs)
 
-			fmt.Println("Custom Attributes:", customAttrs)
+			if mode.EmitLegacy() {
+				// Add default attributes (e.g., HTTP method, path, status code) and combine them with custom attributes
+				allAttrs := metric.WithAttributes(append(httpconv.LegacyResponseAttributes(r.Method, httpconv.RouteOrPath(routePattern, r.URL.Path), ww.Status()), customAttrs...)...)
 
-			// Add default attributes (e.g., HTTP method, path, status code)
-			defaultAttrs := []attribute.KeyValue{
-				attribute.String("http.method", r.Method),
-				attribute.String("http.path", routePattern), // Use normalized path
-				attribute.Int("http.status_code", ww.Status()),
+				// Increment the request counter and record the request duration with all attributes
+				httpMetrics.RequestCounter.Add(r.Context(), 1, allAttrs)
+				httpMetrics.RequestDuration.Record(r.Context(), duration, allAttrs)
+
+				// Record the request and response body sizes with all attributes
+				httpMetrics.RequestSize.Record(r.Context(), body.BytesRead(), allAttrs)
+				httpMetrics.ResponseSize.Record(r.Context(), int64(ww.BytesWritten()), allAttrs)
 			}
+			if mode.EmitStable() {
+				// Same measurements under the stable semantic convention names and attributes
+				allAttrs := metric.WithAttributes(append(httpconv.StableResponseAttributes(r.Method, routePattern, scheme, ww.Status()), customAttrs...)...)
 
-			// Combine default attributes with custom attributes
-			allAttrs := append(defaultAttrs, customAttrs...)
+				httpMetrics.ServerRequestDuration.Record(r.Context(), duration, allAttrs)
+				httpMetrics.ServerRequestBodySize.Record(r.Context(), body.BytesRead(), allAttrs)
+				httpMetrics.ServerResponseBodySize.Record(r.Context(), int64(ww.BytesWritten()), allAttrs)
+			}
+		})
+	}
+}
 
-			// Increment the request counter with all attributes
-			counter.Add(r.Context(), 1, metric.WithAttributes(allAttrs...))
+// requestScheme returns the URL scheme the request was received on.
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
 
-			// Record the request duration with all attributes
-			histogram.Record(r.Context(), duration, metric.WithAttributes(allAttrs...))
-		})
+// resolveRoutePattern returns the chi route pattern a request will be dispatched to. Unlike
+// chi.RouteContext(ctx).RoutePattern(), it also works before routing has happened (i.e. in
+// middleware) by looking the request up in the router. It returns an empty string when the
+// request matches no route.
+func resolveRoutePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return ""
+	}
+	if pattern := rctx.RoutePattern(); pattern != "" {
+		return pattern
 	}
+	if rctx.Routes != nil {
+		if pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path); pattern != "" {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// countingReadCloser wraps a request body and counts the bytes read from it.
+type countingReadCloser struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+// Read implements io.Reader.
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// BytesRead returns the number of bytes read so far.
+func (c *countingReadCloser) BytesRead() int64 {
+	return c.n.Load()
 }
 
 // AddAttribute adds a single custom attribute to the MetricContext. (Write operation)
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultRequestIDHeader is the header a request ID is read from and echoed in by default.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// RequestIDKey is the span attribute and baggage member holding the request ID. Search for
+// this tag in the tracing backend (e.g. request.id=<id> in Jaeger) to find the trace of a request.
+const RequestIDKey = "request.id"
+
+// maxRequestIDLength bounds inbound request IDs so that clients cannot blow up span, baggage
+// and log sizes.
+const maxRequestIDLength = 128
+
+// requestIDConfig holds the optional settings applied by RequestIDMiddleware.
+type requestIDConfig struct {
+	header string // Header the request ID is read from and echoed in
+}
+
+// RequestIDOption customizes RequestIDMiddleware.
+type RequestIDOption func(*requestIDConfig)
+
+// WithRequestIDHeader sets the header an inbound request ID is accepted from and the
+// response header it is echoed in. Defaults to DefaultRequestIDHeader.
+func WithRequestIDHeader(header string) RequestIDOption {
+	return func(c *requestIDConfig) {
+		if header != "" {
+			c.header = header
+		}
+	}
+}
+
+// RequestIDMiddleware assigns every request an ID and makes it available everywhere a
+// request can be looked up from:
+//   - it is stored in the context like chi's middleware.RequestID, so middleware.GetReqID,
+//     LoggingMiddleware and LoggerFromContext keep working
+//   - it is echoed in the response header, so clients can quote it
+//   - it is recorded as the request.id attribute of the active (server) span
+//   - it is added as the request.id baggage member, so outgoing calls propagate it
+//
+// The ID sent by the client or an upstream proxy in the configured header is honored when it
+// is at most 128 printable ASCII characters; otherwise a random ID is generated.
+//
+// The middleware must be installed after the middleware starting the server span
+// (e.g. otelhttp.NewMiddleware) for the span attribute to be recorded.
+//
+// Example usage:
+//
+//	r := chi.NewRouter()
+//	r.Use(otelhttp.NewMiddleware("my-api"))
+//	r.Use(middleware.RequestIDMiddleware(middleware.WithRequestIDHeader("X-Correlation-ID")))
+func RequestIDMiddleware(opts ...RequestIDOption) func(http.Handler) http.Handler {
+	cfg := requestIDConfig{header: DefaultRequestIDHeader}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(cfg.header)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+
+			// Echo the ID before the handler writes the response
+			w.Header().Set(cfg.header, requestID)
+
+			ctx := context.WithValue(r.Context(), middleware.RequestIDKey, requestID)
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String(RequestIDKey, requestID))
+			// Propagate the ID to downstream services along with the trace context
+			if member, err := baggage.NewMemberRaw(RequestIDKey, requestID); err == nil {
+				if bag, err := baggage.FromContext(ctx).SetMember(member); err == nil {
+					ctx = baggage.ContextWithBaggage(ctx, bag)
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// validRequestID reports whether an inbound request ID can be used as is.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 128-bit request ID in hex.
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Response headers written by WithTraceResponse and WithServerTiming.
+const (
+	traceResponseHeader = "Traceresponse"
+	serverTimingHeader  = "Server-Timing"
+)
+
+// maxServerTimingSpans bounds the child span entries of a Server-Timing header.
+const maxServerTimingSpans = 16
+
+// serverTiming is the duration of a child span reported in the Server-Timing header.
+type serverTiming struct {
+	name     string
+	duration time.Duration
+}
+
+// ServerTimingRecorder is a span processor collecting the durations of the direct children of
+// the server spans annotated by TracingMiddleware, so that they can be reported in the
+// Server-Timing header. Register it on the TracerProvider (e.g. with tracing.WithSpanProcessor)
+// and pass it to WithServerTiming.
+//
+// Only child spans that ended before the response headers were written are reported: spans
+// still running when the handler starts writing the body, or ending in a deferred call after
+// it, are left out.
+type ServerTimingRecorder struct {
+	mu      sync.Mutex
+	pending map[trace.SpanID][]serverTiming // Child timings keyed by the ID of their server span
+}
+
+var _ sdktrace.SpanProcessor = (*ServerTimingRecorder)(nil)
+
+// NewServerTimingRecorder creates an empty ServerTimingRecorder.
+//
+// Example usage:
+//
+//	recorder := middleware.NewServerTimingRecorder()
+//	tp, err := tracing.InitTracer(endpoint, "my-api", tracing.WithSpanProcessor(recorder))
+//	r.Use(middleware.TracingMiddleware(middleware.WithServerTiming(recorder)))
+func NewServerTimingRecorder() *ServerTimingRecorder {
+	return &ServerTimingRecorder{pending: make(map[trace.SpanID][]serverTiming)}
+}
+
+// OnStart implements trace.SpanProcessor.
+func (r *ServerTimingRecorder) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+// OnEnd implements trace.SpanProcessor. It records the span if its parent is a tracked server span.
+func (r *ServerTimingRecorder) OnEnd(s sdktrace.ReadOnlySpan) {
+	parent := s.Parent()
+	if !parent.IsValid() || parent.IsRemote() {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	timings, ok := r.pending[parent.SpanID()]
+	if !ok || len(timings) >= maxServerTimingSpans {
+		return
+	}
+	r.pending[parent.SpanID()] = append(timings, serverTiming{
+		name:     s.Name(),
+		duration: s.EndTime().Sub(s.StartTime()),
+	})
+}
+
+// Shutdown implements trace.SpanProcessor.
+func (r *ServerTimingRecorder) Shutdown(context.Context) error { return nil }
+
+// ForceFlush implements trace.SpanProcessor.
+func (r *ServerTimingRecorder) ForceFlush(context.Context) error { return nil }
+
+// track starts collecting the children of the server span spanID. A nil recorder collects nothing.
+func (r *ServerTimingRecorder) track(spanID trace.SpanID) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.pending[spanID] = nil
+	r.mu.Unlock()
+}
+
+// collect stops collecting the children of spanID and returns the timings recorded so far.
+func (r *ServerTimingRecorder) collect(spanID trace.SpanID) []serverTiming {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	timings := r.pending[spanID]
+	delete(r.pending, spanID)
+	return timings
+}
+
+// traceResponse formats the W3C Trace Context traceresponse header value of a span, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func traceResponse(sc trace.SpanContext) string {
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID(), sc.SpanID(), sc.TraceFlags())
+}
+
+// formatServerTiming formats a Server-Timing header value with a "total" metric followed by
+// one metric per child span, e.g. `total;dur=12.5, db_query;dur=3.2;desc="db query"`.
+func formatServerTiming(total time.Duration, children []serverTiming) string {
+	var b strings.Builder
+	b.WriteString("total;dur=")
+	b.WriteString(formatMillis(total))
+	for _, child := range children {
+		name := serverTimingName(child.name)
+		b.WriteString(", ")
+		b.WriteString(name)
+		b.WriteString(";dur=")
+		b.WriteString(formatMillis(child.duration))
+		if name != child.name {
+			b.WriteString(";desc=")
+			b.WriteString(strconv.Quote(child.name))
+		}
+	}
+	return b.String()
+}
+
+// serverTimingName turns a span name into a Server-Timing metric name, which must be an HTTP
+// token: characters outside of the token set are replaced by underscores.
+func serverTimingName(name string) string {
+	return strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			return c
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+			return c
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// formatMillis formats d in milliseconds with microsecond precision, as Server-Timing expects.
+func formatMillis(d time.Duration) string {
+	return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', -1, 64)
+}
+
+// headerHookWriter calls before once, right before the response headers are sent, so that
+// headers depending on the work done so far can still be added.
+type headerHookWriter struct {
+	http.ResponseWriter
+	before func(http.Header)
+	done   bool
+}
+
+// sendHeaders runs the hook unless it already ran.
+func (w *headerHookWriter) sendHeaders() {
+	if w.done {
+		return
+	}
+	w.done = true
+	w.before(w.ResponseWriter.Header())
+}
+
+// WriteHeader implements http.ResponseWriter. Informational responses are passed through
+// without running the hook, as the final headers are still to come.
+func (w *headerHookWriter) WriteHeader(code int) {
+	if code >= http.StatusOK || code == http.StatusSwitchingProtocols {
+		w.sendHeaders()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter.
+func (w *headerHookWriter) Write(b []byte) (int, error) {
+	w.sendHeaders()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher when the underlying writer supports it.
+func (w *headerHookWriter) Flush() {
+	w.sendHeaders()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker when the underlying writer supports it.
+func (w *headerHookWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hj.Hijack()
+	}
+	return nil, nil, fmt.Errorf("response writer %T does not support hijacking", w.ResponseWriter)
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *headerHookWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
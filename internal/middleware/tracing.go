@@ -1,46 +1,229 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"opentelemetry-api/internal/httpconv"
+	"opentelemetry-api/internal/redact"
+	"runtime/debug"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
-func TracingMiddleware(tracer trace.Tracer) func(http.Handler) http.Handler {
+// tracingConfig holds the optional settings applied by TracingMiddleware.
+type tracingConfig struct {
+	semconv         httpconv.Mode         // Span attribute naming
+	requestHeaders  []string              // Request headers recorded as http.request.header.* attributes
+	responseHeaders []string              // Response headers recorded as http.response.header.* attributes
+	redactor        *redact.Redactor      // Redacts captured header values, nil for the default rules
+	traceResponse   bool                  // Write the traceresponse header
+	serverTiming    bool                  // Write the Server-Timing header
+	timingRecorder  *ServerTimingRecorder // Child span timings for Server-Timing, nil for the total only
+}
+
+// TracingOption customizes TracingMiddleware.
+type TracingOption func(*tracingConfig)
+
+// WithTracingSemconv selects legacy, stable or duplicated span attribute names.
+// Defaults to httpconv.ModeLegacy.
+func WithTracingSemconv(mode httpconv.Mode) TracingOption {
+	return func(c *tracingConfig) {
+		c.semconv = mode
+	}
+}
+
+// WithTracingHeaders records the listed request and response headers as
+// http.request.header.<name> and http.response.header.<name> span attributes. Values of
+// sensitive headers (e.g. Authorization, Cookie) are redacted, by default with redact.DefaultRules.
+func WithTracingHeaders(request, response []string) TracingOption {
+	return func(c *tracingConfig) {
+		c.requestHeaders = request
+		c.responseHeaders = response
+	}
+}
+
+// WithTracingRedactor sets the redactor applied to captured header values.
+func WithTracingRedactor(redactor *redact.Redactor) TracingOption {
+	return func(c *tracingConfig) {
+		c.redactor = redactor
+	}
+}
+
+// WithTraceResponse writes the W3C traceresponse header holding the trace ID, server span ID
+// and sampled flag on every response, so that clients can quote the trace of a request.
+func WithTraceResponse(enabled bool) TracingOption {
+	return func(c *tracingConfig) {
+		c.traceResponse = enabled
+	}
+}
+
+// WithServerTiming writes a Server-Timing header on every response with the time spent since
+// the server span started as the "total" metric. With a non-nil recorder, the durations of the
+// child spans that ended before the response headers were written are reported as well.
+func WithServerTiming(recorder *ServerTimingRecorder) TracingOption {
+	return func(c *tracingConfig) {
+		c.serverTiming = true
+		c.timingRecorder = recorder
+	}
+}
+
+// TracingMiddleware annotates the server span already active in the request context with the
+// HTTP response attributes, captured headers, status and panics. It does not start or rename the
+// span: name it when it is started, e.g. with otelhttp.WithSpanNameFormatter as
+// httpserver.NewRouter does.
+func TracingMiddleware(opts ...TracingOption) func(http.Handler) http.Handler {
+	var cfg tracingConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Extract existing span from the context which is alredy started by
 			// r.Use(otelhttp.NewMiddleware(serviceName)) in app initialization
 			span := trace.SpanFromContext(r.Context())
 
-			// Extract the trace ID from the span context
-			traceID := span.SpanContext().TraceID().String()
+			// Log entries get trace_id and span_id from logging.Context, resolved against the span
+			// active when they are written, so they are not stored in the LoggingContext here
 			spanID := span.SpanContext().SpanID()
-			// Retrieve the LoggingContext
-			loggingContext := GetLoggingContext(r.Context())
-			// Add custom attributes
-			loggingContext.AddAttribute("trace_id", traceID)
-			loggingContext.AddAttribute("span_id", spanID)
-
-			// Pass the updated context to the next handler
-			next.ServeHTTP(w, r)
 
-			// Extract the normalized route pattern from the Chi router
-			routePattern := chi.RouteContext(r.Context()).RoutePattern()
-			if routePattern == "" {
-				// Fallback to the raw path if no route pattern is found
-				routePattern = r.URL.Path
+			// Add the trace response headers right before the response headers are sent
+			var hw *headerHookWriter
+			if cfg.traceResponse || cfg.serverTiming {
+				cfg.timingRecorder.track(spanID)
+				start := spanStartTime(span)
+				hw = &headerHookWriter{ResponseWriter: w, before: func(h http.Header) {
+					if cfg.traceResponse && span.SpanContext().IsValid() {
+						h.Set(traceResponseHeader, traceResponse(span.SpanContext()))
+					}
+					if cfg.serverTiming {
+						h.Set(serverTimingHeader, formatServerTiming(time.Since(start), cfg.timingRecorder.collect(spanID)))
+					}
+				}}
+				w = hw
 			}
 
-			// Add HTTP attributes to the span
-			span.SetAttributes(
-				attribute.String("http.method", r.Method),
-				attribute.String("http.path", routePattern), // Use normalized path
-			)
-			// Update the span name to the pattern e.g. /{id} instead of /1
-			span.SetName(r.Method + " " + routePattern)
+			// Wrap the response writer to capture the status code
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			// Annotate the span once the handler returned, including when it panicked
+			defer func() {
+				panicValue := recover()
+
+				if hw != nil {
+					// Handlers that never write get an implicit 200 once they return, and panics a 500
+					// from the recoverer: both share the header map, so the headers are set here
+					hw.sendHeaders()
+					cfg.timingRecorder.collect(spanID)
+				}
+
+				// Extract the normalized route pattern from the Chi router, empty if no route matched
+				routePattern := chi.RouteContext(r.Context()).RoutePattern()
+
+				status := ww.Status()
+				if panicValue != nil {
+					// The response is written by an outer recoverer (e.g. chi's Recoverer)
+					status = http.StatusInternalServerError
+					if panicValue != http.ErrAbortHandler {
+						recordPanic(span, panicValue)
+					}
+				}
+
+				// Add HTTP attributes to the span, using the configured naming
+				span.SetAttributes(cfg.semconv.ResponseAttributes(r.Method, routePattern, r.URL.Path, requestScheme(r), status)...)
+				// Record the allow-listed request and response headers
+				span.SetAttributes(headerAttributes(captureHeaders(requestHeaderPrefix, r.Header, cfg.requestHeaders, cfg.redactor))...)
+				span.SetAttributes(headerAttributes(captureHeaders(responseHeaderPrefix, ww.Header(), cfg.responseHeaders, cfg.redactor))...)
+				// Server errors fail the span (panics already did); 4xx responses are the client's fault
+				// and leave it unset. otelhttp sets the status again once the outer layers have written
+				// the response, clearing the description, so the cause is kept in error.type and in the
+				// exception events instead
+				if panicValue == nil && status >= http.StatusInternalServerError {
+					span.SetStatus(codes.Error, http.StatusText(status))
+					if !hasAttribute(span, errorTypeKey) {
+						// Keep the more specific type recorded by RecordError, if any
+						span.SetAttributes(errorTypeKey.String(strconv.Itoa(status)))
+					}
+				}
+
+				if panicValue != nil {
+					// Let the recoverer further up the chain handle the panic
+					panic(panicValue)
+				}
+			}()
+
+			// Pass the updated context to the next handler
+			next.ServeHTTP(ww, r)
 		})
 	}
 }
+
+// errorTypeKey is the semantic convention attribute describing the class of error a request ended with.
+const errorTypeKey = attribute.Key("error.type")
+
+// RecordError records err on the span active in ctx as an exception event, sets the span status
+// to Error and adds an error.type attribute holding the Go type of err (e.g. "*pgconn.PgError").
+// The error message is kept in the exception event, as the status description may be replaced
+// by the instrumentation ending the server span.
+// Handlers use it to fail the request span for errors that are not reflected in a 5xx status,
+// or to attach the underlying cause of one.
+//
+// Example usage:
+//
+//	if err := repo.Save(ctx, user); err != nil {
+//		middleware.RecordError(ctx, err)
+//		http.Error(w, "failed to save user", http.StatusInternalServerError)
+//		return
+//	}
+func RecordError(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err, trace.WithStackTrace(true))
+	span.SetStatus(codes.Error, err.Error())
+	span.SetAttributes(errorTypeKey.String(fmt.Sprintf("%T", err)))
+}
+
+// hasAttribute reports whether span, when recorded by the SDK, already carries the key attribute.
+func hasAttribute(span trace.Span, key attribute.Key) bool {
+	s, ok := span.(interface{ Attributes() []attribute.KeyValue })
+	if !ok {
+		return false
+	}
+	for _, kv := range s.Attributes() {
+		if kv.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// spanStartTime returns the start time of span when it is recorded by the SDK, or now otherwise.
+func spanStartTime(span trace.Span) time.Time {
+	if s, ok := span.(interface{ StartTime() time.Time }); ok {
+		return s.StartTime()
+	}
+	return time.Now()
+}
+
+// recordPanic records a recovered panic value as an exception event with the stack trace of
+// the panicking goroutine and fails the span.
+func recordPanic(span trace.Span, value any) {
+	message := fmt.Sprint(value)
+	span.AddEvent("exception", trace.WithAttributes(
+		attribute.String("exception.type", fmt.Sprintf("%T", value)),
+		attribute.String("exception.message", message),
+		attribute.String("exception.stacktrace", string(debug.Stack())),
+		attribute.Bool("exception.escaped", true),
+	))
+	span.SetStatus(codes.Error, "panic: "+message)
+	span.SetAttributes(errorTypeKey.String(fmt.Sprintf("%T", value)))
+}
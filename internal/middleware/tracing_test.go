@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// serveTraced serves a single request to handler behind otelhttp, chi's Recoverer and
+// TracingMiddleware, and returns the response and the only span that was ended.
+func serveTraced(t *testing.T, handler http.HandlerFunc) (*httptest.ResponseRecorder, sdktrace.ReadOnlySpan) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	r := chi.NewRouter()
+	r.Use(otelhttp.NewMiddleware("test", otelhttp.WithTracerProvider(tp)))
+	r.Use(middleware.Recoverer)
+	r.Use(TracingMiddleware())
+	r.Get("/test", handler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want exactly 1", len(spans))
+	}
+	return w, spans[0]
+}
+
+// spanAttribute returns the value of the key attribute of span, or false when it is missing.
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracingMiddlewareRecordsPanic(t *testing.T) {
+	w, span := serveTraced(t, func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("span status = %v, want Error", span.Status().Code)
+	}
+	if v, ok := spanAttribute(span, errorTypeKey); !ok || v.AsString() != "string" {
+		t.Errorf("error.type = %v, want \"string\"", v.Emit())
+	}
+
+	var found bool
+	for _, event := range span.Events() {
+		if event.Name != "exception" {
+			continue
+		}
+		found = true
+		attrs := attribute.NewSet(event.Attributes...)
+		if v, _ := attrs.Value("exception.message"); v.AsString() != "boom" {
+			t.Errorf("exception.message = %q, want \"boom\"", v.AsString())
+		}
+		if v, _ := attrs.Value("exception.stacktrace"); !strings.Contains(v.AsString(), "goroutine") {
+			t.Errorf("exception.stacktrace = %q, want a stack trace", v.AsString())
+		}
+	}
+	if !found {
+		t.Error("span has no exception event")
+	}
+}
+
+func TestTracingMiddlewareRepanics(t *testing.T) {
+	defer func() {
+		if v := recover(); v != "boom" {
+			t.Errorf("recovered %v, want the original panic value", v)
+		}
+	}()
+
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "server")
+	defer span.End()
+
+	handler := TracingMiddleware()(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil).WithContext(ctx))
+	t.Error("TracingMiddleware swallowed the panic")
+}
+
+func TestTracingMiddlewareServerError(t *testing.T) {
+	_, span := serveTraced(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	if span.Status().Code != codes.Error {
+		t.Errorf("span status = %v, want Error", span.Status().Code)
+	}
+	if v, ok := spanAttribute(span, errorTypeKey); !ok || v.AsString() != "503" {
+		t.Errorf("error.type = %v, want \"503\"", v.Emit())
+	}
+}
+
+func TestTracingMiddlewareClientErrorLeavesStatusUnset(t *testing.T) {
+	_, span := serveTraced(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	if span.Status().Code != codes.Unset {
+		t.Errorf("span status = %v, want Unset", span.Status().Code)
+	}
+	if _, ok := spanAttribute(span, errorTypeKey); ok {
+		t.Error("error.type is set on a 4xx response")
+	}
+}
+
+func TestRecordErrorKeepsErrorType(t *testing.T) {
+	_, span := serveTraced(t, func(w http.ResponseWriter, r *http.Request) {
+		RecordError(r.Context(), errors.New("connection refused"))
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	if v, ok := spanAttribute(span, errorTypeKey); !ok || v.AsString() != "*errors.errorString" {
+		t.Errorf("error.type = %v, want \"*errors.errorString\"", v.Emit())
+	}
+	var message string
+	for _, event := range span.Events() {
+		if event.Name == "exception" {
+			attrs := attribute.NewSet(event.Attributes...)
+			v, _ := attrs.Value("exception.message")
+			message = v.AsString()
+		}
+	}
+	if message != "connection refused" {
+		t.Errorf("exception.message = %q, want \"connection refused\"", message)
+	}
+}
@@ -0,0 +1,51 @@
+package otlpconfig
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// GRPCTransport is the connection setup shared by the OTLP/gRPC trace, metric and log exporters.
+// Each exporter package has its own option types, so the exporters apply it themselves:
+// Credentials through their WithTLSCredentials option (or WithInsecure when nil) and
+// DialOptions through their WithDialOption option.
+type GRPCTransport struct {
+	Credentials credentials.TransportCredentials // TLS credentials, nil for a plaintext connection
+	DialOptions []grpc.DialOption                // Per-RPC bearer token credentials, if any
+}
+
+// NewGRPCTransport builds the transport described by tls and token. The token file is attached
+// as per-RPC credentials, so it is re-read whenever it changes. It fails when the TLS files
+// cannot be loaded, or when the token would be sent over a plaintext connection without
+// TokenFile.AllowInsecure.
+//
+// Example usage:
+//
+//	transport, err := otlpconfig.NewGRPCTransport(tlsConfig, token)
+//	if err != nil {
+//	    return nil, err
+//	}
+//	opts := []otlptracegrpc.Option{otlptracegrpc.WithDialOption(transport.DialOptions...)}
+//	if transport.Credentials == nil {
+//	    opts = append(opts, otlptracegrpc.WithInsecure())
+//	} else {
+//	    opts = append(opts, otlptracegrpc.WithTLSCredentials(transport.Credentials))
+//	}
+func NewGRPCTransport(tls TLSConfig, token *TokenFile) (GRPCTransport, error) {
+	if err := token.CheckTransport(tls); err != nil {
+		return GRPCTransport{}, err
+	}
+	tlsCfg, err := tls.ClientConfig()
+	if err != nil {
+		return GRPCTransport{}, err
+	}
+
+	var transport GRPCTransport
+	if tlsCfg != nil {
+		transport.Credentials = credentials.NewTLS(tlsCfg)
+	}
+	if token != nil {
+		transport.DialOptions = append(transport.DialOptions, grpc.WithPerRPCCredentials(token))
+	}
+	return transport, nil
+}
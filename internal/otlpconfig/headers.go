@@ -0,0 +1,168 @@
+package otlpconfig
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// ParseHeaders parses the OTEL_EXPORTER_OTLP_HEADERS format: a comma-separated list of
+// key=value pairs whose values are URL-encoded, e.g. "api-key=secret,x-tenant=team%20a".
+//
+// Example usage:
+//
+//	headers, err := ParseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+//	if err != nil {
+//	    log.Fatalf("invalid OTLP headers: %v", err)
+//	}
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid header %q: expected key=value", pair)
+		}
+		decoded, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for header %q: %w", key, err)
+		}
+		headers[strings.ToLower(key)] = decoded
+	}
+	return headers, nil
+}
+
+// TokenFile supplies a bearer token read from a file, such as a mounted Kubernetes secret.
+// The file is re-read whenever its modification time or size changes, so rotated secrets are
+// picked up without restarting the process.
+//
+// TokenFile implements credentials.PerRPCCredentials, which attaches the current token to every
+// gRPC export call. The token is only sent over TLS unless AllowInsecure is called.
+type TokenFile struct {
+	path          string
+	allowInsecure bool // Send the token over plaintext connections too
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	token   string
+}
+
+var _ credentials.PerRPCCredentials = (*TokenFile)(nil)
+
+// NewTokenFile reads the token at path. It fails if the file cannot be read or is empty.
+func NewTokenFile(path string) (*TokenFile, error) {
+	t := &TokenFile{path: path}
+	if _, err := t.Token(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Token returns the current token, re-reading the file if it changed since the last call.
+// If the file becomes unreadable the last known token keeps being used.
+func (t *TokenFile) Token() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if t.token != "" {
+			return t.token, nil
+		}
+		return "", fmt.Errorf("failed to stat token file: %w", err)
+	}
+	if t.token != "" && info.ModTime().Equal(t.modTime) && info.Size() == t.size {
+		return t.token, nil
+	}
+
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		if t.token != "" {
+			return t.token, nil
+		}
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		if t.token != "" {
+			return t.token, nil
+		}
+		return "", fmt.Errorf("token file %s is empty", t.path)
+	}
+
+	t.token, t.modTime, t.size = token, info.ModTime(), info.Size()
+	return t.token, nil
+}
+
+// Header returns the authorization header carrying the current token.
+func (t *TokenFile) Header() (map[string]string, error) {
+	token, err := t.Token()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (t *TokenFile) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return t.Header()
+}
+
+// AllowInsecure lets the token be sent over plaintext connections, e.g. to a sidecar collector
+// listening on localhost. It returns t for chaining.
+func (t *TokenFile) AllowInsecure() *TokenFile {
+	t.allowInsecure = true
+	return t
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials. gRPC refuses to send the
+// token over a plaintext connection unless AllowInsecure was called.
+func (t *TokenFile) RequireTransportSecurity() bool {
+	return !t.allowInsecure
+}
+
+// CheckTransport returns an error when the token would be sent in clear text, i.e. when tls
+// disables TLS and AllowInsecure was not called. A nil TokenFile passes the check. Call it at
+// startup to fail fast instead of on every export.
+//
+// Example usage:
+//
+//	if err := token.CheckTransport(otlpTLS); err != nil {
+//	    log.Fatalf("invalid OTLP configuration: %v", err)
+//	}
+func (t *TokenFile) CheckTransport(tls TLSConfig) error {
+	if t == nil || !tls.Insecure || t.allowInsecure {
+		return nil
+	}
+	return fmt.Errorf("refusing to send the OTLP token from %s over a plaintext connection: configure TLS or allow insecure token transport", t.path)
+}
+
+// MergeHeaders returns the static headers together with the current token header, for exporters
+// that only accept a fixed header set at construction time (e.g. OTLP over HTTP). Such exporters
+// keep using the token read at startup.
+func MergeHeaders(headers map[string]string, token *TokenFile) (map[string]string, error) {
+	merged := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		merged[k] = v
+	}
+	if token != nil {
+		auth, err := token.Header()
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range auth {
+			merged[k] = v
+		}
+	}
+	return merged, nil
+}
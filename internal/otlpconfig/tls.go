@@ -0,0 +1,71 @@
+// Package otlpconfig holds the connection settings shared by the OTLP trace, metric and log
+// exporters, so that every signal reaches the collector the same way.
+package otlpconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSConfig describes how OTLP exporters secure their connection to the collector.
+//
+// The fields mirror the standard exporter environment variables:
+//   - Insecure: OTEL_EXPORTER_OTLP_INSECURE, disables TLS entirely.
+//   - CAFile: OTEL_EXPORTER_OTLP_CERTIFICATE, PEM bundle used to verify the collector.
+//   - CertFile/KeyFile: OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE/_CLIENT_KEY, client certificate for mTLS.
+//   - ServerName: overrides the name checked against the collector certificate.
+type TLSConfig struct {
+	Insecure   bool   // Use a plaintext connection (no TLS)
+	CAFile     string // CA bundle for verifying the collector; system roots when empty
+	CertFile   string // Client certificate presented for mTLS
+	KeyFile    string // Private key matching CertFile
+	ServerName string // Server name override for certificate verification
+}
+
+// ClientConfig builds the *tls.Config for the exporters. It returns nil when Insecure is set.
+//
+// Example usage:
+//
+//	tlsCfg, err := TLSConfig{CAFile: "/etc/otel/ca.pem"}.ClientConfig()
+//	if err != nil {
+//	    log.Fatalf("invalid OTLP TLS configuration: %v", err)
+//	}
+func (c TLSConfig) ClientConfig() (*tls.Config, error) {
+	if c.Insecure {
+		return nil, nil
+	}
+
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.ServerName,
+	}
+
+	// Load the CA bundle used to verify the collector certificate
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OTLP CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificates found in %s", c.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	// Load the client certificate for mutual TLS
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, fmt.Errorf("both a client certificate and a client key are required for mTLS")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load OTLP client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
@@ -0,0 +1,351 @@
+package otlpconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// testPKI is a throwaway CA with a server certificate for "collector.test" and a client
+// certificate, written as PEM files to a temporary directory.
+type testPKI struct {
+	caFile, serverCertFile, serverKeyFile, clientCertFile, clientKeyFile string
+	caPool                                                               *x509.CertPool
+}
+
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey := newKey(t)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issue := func(serial int64, name string, usage x509.ExtKeyUsage) (certFile, keyFile string) {
+		key := newKey(t)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certFile = writePEM(t, dir, name+".crt", "CERTIFICATE", der)
+		keyFile = writePEM(t, dir, name+".key", "EC PRIVATE KEY", keyDER)
+		return certFile, keyFile
+	}
+
+	pki := testPKI{caFile: writePEM(t, dir, "ca.crt", "CERTIFICATE", caDER), caPool: x509.NewCertPool()}
+	pki.caPool.AddCert(caCert)
+	pki.serverCertFile, pki.serverKeyFile = issue(2, "collector.test", x509.ExtKeyUsageServerAuth)
+	pki.clientCertFile, pki.clientKeyFile = issue(3, "client.test", x509.ExtKeyUsageClientAuth)
+	return pki
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestClientConfigInsecure(t *testing.T) {
+	cfg, err := TLSConfig{Insecure: true, CAFile: "/does/not/exist"}.ClientConfig()
+	if err != nil || cfg != nil {
+		t.Errorf("ClientConfig() = %v, %v, want nil, nil", cfg, err)
+	}
+}
+
+func TestClientConfig(t *testing.T) {
+	pki := newTestPKI(t)
+
+	cfg, err := TLSConfig{
+		CAFile:     pki.caFile,
+		CertFile:   pki.clientCertFile,
+		KeyFile:    pki.clientKeyFile,
+		ServerName: "collector.test",
+	}.ClientConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MinVersion != tls.VersionTLS12 {
+		t.Errorf("MinVersion = %x, want TLS 1.2", cfg.MinVersion)
+	}
+	if cfg.ServerName != "collector.test" {
+		t.Errorf("ServerName = %q, want %q", cfg.ServerName, "collector.test")
+	}
+	if cfg.RootCAs == nil || !cfg.RootCAs.Equal(pki.caPool) {
+		t.Error("RootCAs does not hold the configured CA")
+	}
+	if len(cfg.Certificates) != 1 {
+		t.Errorf("got %d client certificates, want 1", len(cfg.Certificates))
+	}
+}
+
+func TestClientConfigSystemRoots(t *testing.T) {
+	cfg, err := TLSConfig{}.ClientConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RootCAs != nil || len(cfg.Certificates) != 0 {
+		t.Error("expected the system roots and no client certificate")
+	}
+}
+
+func TestClientConfigErrors(t *testing.T) {
+	pki := newTestPKI(t)
+	notPEM := filepath.Join(t.TempDir(), "not.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]TLSConfig{
+		"missing CA file":     {CAFile: "/does/not/exist"},
+		"CA file without PEM": {CAFile: notPEM},
+		"cert without key":    {CertFile: pki.clientCertFile},
+		"key without cert":    {KeyFile: pki.clientKeyFile},
+		"mismatched key":      {CertFile: pki.clientCertFile, KeyFile: pki.serverKeyFile},
+	}
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := c.ClientConfig(); err == nil {
+				t.Error("ClientConfig() succeeded, want an error")
+			}
+		})
+	}
+}
+
+// traceCollector is a stub OTLP trace service recording the metadata of export calls.
+type traceCollector struct {
+	collectortrace.UnimplementedTraceServiceServer
+	metadata chan metadata.MD
+}
+
+func (c *traceCollector) Export(ctx context.Context, _ *collectortrace.ExportTraceServiceRequest) (*collectortrace.ExportTraceServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.metadata <- md
+	return &collectortrace.ExportTraceServiceResponse{}, nil
+}
+
+// startTLSCollector serves a traceCollector over TLS, requiring a client certificate issued by
+// the test CA, and returns its address.
+func startTLSCollector(t *testing.T, pki testPKI) (string, *traceCollector) {
+	t.Helper()
+	serverCert, err := tls.LoadX509KeyPair(pki.serverCertFile, pki.serverKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pki.caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := &traceCollector{metadata: make(chan metadata.MD, 1)}
+	server := grpc.NewServer(grpc.Creds(creds))
+	collectortrace.RegisterTraceServiceServer(server, collector)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	return lis.Addr().String(), collector
+}
+
+// exportSpan sends one span to addr through an OTLP/gRPC exporter using transport.
+func exportSpan(t *testing.T, addr string, transport GRPCTransport) error {
+	t.Helper()
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(addr),
+		otlptracegrpc.WithDialOption(transport.DialOptions...),
+		otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{Enabled: false}),
+		otlptracegrpc.WithTimeout(5 * time.Second),
+	}
+	if transport.Credentials == nil {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	} else {
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(transport.Credentials))
+	}
+
+	ctx := context.Background()
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = exporter.Shutdown(ctx) }()
+	return exporter.ExportSpans(ctx, tracetest.SpanStubs{{Name: "test"}}.Snapshots())
+}
+
+func TestGRPCTransportMutualTLSHandshake(t *testing.T) {
+	pki := newTestPKI(t)
+	addr, collector := startTLSCollector(t, pki)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	token, err := NewTokenFile(tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The server certificate is issued for collector.test, not for 127.0.0.1
+	transport, err := NewGRPCTransport(TLSConfig{
+		CAFile:     pki.caFile,
+		CertFile:   pki.clientCertFile,
+		KeyFile:    pki.clientKeyFile,
+		ServerName: "collector.test",
+	}, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exportSpan(t, addr, transport); err != nil {
+		t.Fatalf("export over mTLS failed: %v", err)
+	}
+
+	md := <-collector.metadata
+	if got := md.Get("authorization"); len(got) != 1 || got[0] != "Bearer s3cr3t" {
+		t.Errorf("authorization = %v, want [Bearer s3cr3t]", got)
+	}
+}
+
+func TestGRPCTransportSendsRotatedToken(t *testing.T) {
+	pki := newTestPKI(t)
+	addr, collector := startTLSCollector(t, pki)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("token-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	token, err := NewTokenFile(tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, err := NewGRPCTransport(TLSConfig{
+		CAFile:     pki.caFile,
+		CertFile:   pki.clientCertFile,
+		KeyFile:    pki.clientKeyFile,
+		ServerName: "collector.test",
+	}, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []string{"token-1", "token-2", "token-3"} {
+		if i > 0 {
+			// Rotate the secret in place with a token of the same size, as a mounted secret update
+			// would. The modification time is moved forward explicitly, as two writes within the
+			// file system's timestamp granularity would otherwise look unchanged
+			if err := os.WriteFile(tokenFile, []byte(want+"\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			modTime := time.Now().Add(time.Duration(i) * time.Second)
+			if err := os.Chtimes(tokenFile, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := exportSpan(t, addr, transport); err != nil {
+			t.Fatalf("export failed: %v", err)
+		}
+		md := <-collector.metadata
+		if got := md.Get("authorization"); len(got) != 1 || got[0] != "Bearer "+want {
+			t.Errorf("authorization = %v, want [Bearer %s]", got, want)
+		}
+	}
+}
+
+func TestGRPCTransportHandshakeFailures(t *testing.T) {
+	pki := newTestPKI(t)
+	addr, _ := startTLSCollector(t, pki)
+
+	tests := map[string]TLSConfig{
+		"server name mismatch": {CAFile: pki.caFile, CertFile: pki.clientCertFile, KeyFile: pki.clientKeyFile},
+		"unknown CA":           {CertFile: pki.clientCertFile, KeyFile: pki.clientKeyFile, ServerName: "collector.test"},
+		"no client cert":       {CAFile: pki.caFile, ServerName: "collector.test"},
+	}
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			transport, err := NewGRPCTransport(c, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := exportSpan(t, addr, transport); err == nil {
+				t.Error("export succeeded, want a handshake failure")
+			}
+		})
+	}
+}
+
+func TestGRPCTransportRefusesPlaintextToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("s3cr3t"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	token, err := NewTokenFile(tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGRPCTransport(TLSConfig{Insecure: true}, token); err == nil {
+		t.Error("NewGRPCTransport() succeeded for a token over plaintext, want an error")
+	}
+	if _, err := NewGRPCTransport(TLSConfig{Insecure: true}, token.AllowInsecure()); err != nil {
+		t.Errorf("NewGRPCTransport() with AllowInsecure: %v", err)
+	}
+}
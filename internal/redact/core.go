@@ -0,0 +1,168 @@
+package redact
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactCore applies a Redactor to the message and text-like fields of every entry.
+type redactCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+// NewCore wraps core so that the patterns of redactor are applied to log messages and fields
+// (including fields added with With). Wrap each output core before teeing them rather than the
+// tee itself: a wrapped tee would be checked as a whole, writing entries enabled on one output
+// to all of them regardless of their own levels.
+//
+//	core := zapcore.NewTee(redact.NewCore(stdoutCore, redactor), redact.NewCore(otelCore, redactor))
+//
+// Covered field types are strings, byte strings (zap.ByteString), errors (zap.Error,
+// zap.NamedError), fmt.Stringers (zap.Stringer), arrays and objects (typed slices such as
+// zap.Strings and zap.Errors, zap.Array, zap.Object) and values logged by reflection (zap.Any on
+// structs, maps and slices, zap.Reflect). A redacted error is logged by its message only, without
+// the errorVerbose detail, and a redacted array or object is logged by reflection. zap.Binary is
+// not redacted: keep secrets out of it.
+func NewCore(core zapcore.Core, redactor *Redactor) zapcore.Core {
+	if redactor == nil {
+		return core
+	}
+	return redactCore{Core: core, redactor: redactor}
+}
+
+// With implements zapcore.Core.
+func (c redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactCore{Core: c.Core.With(c.fields(fields)), redactor: c.redactor}
+}
+
+// Check implements zapcore.Core.
+func (c redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core.
+func (c redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.redactor.String(ent.Message)
+	return c.Core.Write(ent, c.fields(fields))
+}
+
+// fields returns fields with their values redacted, copying the slice only when needed.
+func (c redactCore) fields(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i, f := range fields {
+		value, ok := c.field(f)
+		if !ok {
+			continue
+		}
+		if redacted == nil {
+			redacted = append([]zapcore.Field(nil), fields...)
+		}
+		redacted[i] = value
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+// field returns the redacted version of f, and false when f is left unchanged.
+func (c redactCore) field(f zapcore.Field) (zapcore.Field, bool) {
+	switch f.Type {
+	case zapcore.StringType:
+		if value := c.redactor.String(f.String); value != f.String {
+			f.String = value
+			return f, true
+		}
+	case zapcore.ByteStringType:
+		if b, ok := f.Interface.([]byte); ok {
+			if value := c.redactor.String(string(b)); value != string(b) {
+				return zap.ByteString(f.Key, []byte(value)), true
+			}
+		}
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			message := safeString(err.Error)
+			if value := c.redactor.String(message); value != message {
+				return zap.NamedError(f.Key, errors.New(value)), true
+			}
+		}
+	case zapcore.StringerType:
+		if stringer, ok := f.Interface.(fmt.Stringer); ok && stringer != nil {
+			text := safeString(stringer.String)
+			if value := c.redactor.String(text); value != text {
+				return zap.String(f.Key, value), true
+			}
+		}
+	case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType:
+		// Encode the value to plain maps and slices, which are logged instead when redacted
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		if value, ok := c.value(enc.Fields[f.Key]); ok {
+			return zap.Reflect(f.Key, value), true
+		}
+	case zapcore.ReflectType:
+		// Redact the JSON encoding and decode it again, so that the value keeps its structure
+		data, err := json.Marshal(f.Interface)
+		if err != nil {
+			return f, false
+		}
+		value := c.redactor.String(string(data))
+		if value == string(data) {
+			return f, false
+		}
+		var decoded any
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			return zap.String(f.Key, value), true
+		}
+		return zap.Reflect(f.Key, decoded), true
+	}
+	return f, false
+}
+
+// value redacts the strings in a value built by zapcore.MapObjectEncoder, returning false when
+// none of them changed.
+func (c redactCore) value(v any) (any, bool) {
+	switch v := v.(type) {
+	case string:
+		value := c.redactor.String(v)
+		return value, value != v
+	case []any:
+		var changed bool
+		redacted := make([]any, len(v))
+		for i, elem := range v {
+			var ok bool
+			redacted[i], ok = c.value(elem)
+			changed = changed || ok
+		}
+		return redacted, changed
+	case map[string]any:
+		var changed bool
+		redacted := make(map[string]any, len(v))
+		for key, elem := range v {
+			var ok bool
+			redacted[key], ok = c.value(elem)
+			changed = changed || ok
+		}
+		return redacted, changed
+	}
+	return v, false
+}
+
+// safeString calls fn, turning a panic (e.g. a method called on a nil pointer) into a
+// placeholder the way zap does when encoding the field.
+func safeString(fn func() string) (s string) {
+	defer func() {
+		if v := recover(); v != nil {
+			s = fmt.Sprintf("<PANIC=%v>", v)
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,94 @@
+package redact
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// account is logged with zap.Object.
+type account struct {
+	email string
+}
+
+func (a account) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("email", a.email)
+	return nil
+}
+
+func TestCoreRedactsFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		field zap.Field
+		want  any
+	}{
+		{"string", zap.String("user", "bob@example.com"), "[REDACTED]"},
+		{"byte string", zap.ByteString("user", []byte("bob@example.com")), "[REDACTED]"},
+		{"error", zap.Error(errors.New("no user bob@example.com")), "no user [REDACTED]"},
+		{"strings", zap.Strings("users", []string{"bob@example.com", "alice"}), []any{"[REDACTED]", "alice"}},
+		{"object", zap.Object("account", account{email: "bob@example.com"}), map[string]any{"email": "[REDACTED]"}},
+		{"reflected", zap.Any("users", map[string]string{"bob": "bob@example.com"}), map[string]any{"bob": "[REDACTED]"}},
+		{"unchanged", zap.Strings("users", []string{"alice"}), []any{"alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.InfoLevel)
+			zap.New(NewCore(core, Default())).Info("lookup", tt.field)
+
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+			var got any
+			for key, value := range entries[0].ContextMap() {
+				got = value
+				if key != tt.field.Key {
+					t.Errorf("field key = %q, want %q", key, tt.field.Key)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("field = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoreRedactsMessageAndWithFields(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(NewCore(core, Default())).With(zap.String("user", "bob@example.com"))
+	logger.Info("signup from alice@example.com")
+
+	entry := logs.All()[0]
+	if entry.Message != "signup from [REDACTED]" {
+		t.Errorf("message = %q, want it redacted", entry.Message)
+	}
+	if got := entry.ContextMap()["user"]; got != "[REDACTED]" {
+		t.Errorf("user = %v, want it redacted", got)
+	}
+}
+
+func TestCoreKeepsLevelsOfTeedCores(t *testing.T) {
+	debugCore, debugLogs := observer.New(zapcore.DebugLevel)
+	infoCore, infoLogs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(zapcore.NewTee(NewCore(debugCore, Default()), NewCore(infoCore, Default())))
+
+	logger.Debug("details for bob@example.com")
+
+	if debugLogs.Len() != 1 {
+		t.Errorf("debug core got %d entries, want 1", debugLogs.Len())
+	}
+	if infoLogs.Len() != 0 {
+		t.Errorf("info core got %d debug entries, want 0", infoLogs.Len())
+	}
+}
+
+func TestNewCoreNilRedactor(t *testing.T) {
+	core, _ := observer.New(zapcore.InfoLevel)
+	if got := NewCore(core, nil); got != core {
+		t.Error("NewCore(core, nil) wrapped core, want it unchanged")
+	}
+}
@@ -0,0 +1,248 @@
+// Package redact removes sensitive data (credentials, personal data, SQL literals) from log
+// fields, span attributes and metric attributes, using one set of rules for every signal.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultReplacement replaces redacted values when Rules.Replacement is empty.
+const DefaultReplacement = "[REDACTED]"
+
+// Rules describes what a Redactor removes.
+//
+// Example rules file (fields that are omitted keep their DefaultRules value):
+//
+//	{
+//	  "query_params": ["token", "access_token", "password"],
+//	  "headers": ["authorization", "cookie", "x-api-key"],
+//	  "patterns": ["[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\\.[A-Za-z]{2,}", "(?i)bearer\\s+[A-Za-z0-9._~+/=-]+"],
+//	  "sql_literals": true
+//	}
+type Rules struct {
+	QueryParams []string `json:"query_params"` // Query parameter names whose values are redacted (case-insensitive)
+	Headers     []string `json:"headers"`      // Header names whose values are redacted (case-insensitive)
+	Patterns    []string `json:"patterns"`     // Regular expressions whose matches are redacted in any string
+	SQLLiterals bool     `json:"sql_literals"` // Replace string and numeric literals in SQL statements with "?"
+	Replacement string   `json:"replacement"`  // Replacement for redacted values (default DefaultReplacement)
+}
+
+// DefaultRules returns rules covering common credentials, e-mail addresses and SQL literals.
+func DefaultRules() Rules {
+	return Rules{
+		QueryParams: []string{"token", "access_token", "refresh_token", "id_token", "api_key", "apikey", "key", "password", "secret", "client_secret", "code", "signature", "sig"},
+		Headers:     []string{"authorization", "proxy-authorization", "cookie", "set-cookie", "x-api-key", "x-auth-token"},
+		Patterns: []string{
+			`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`, // E-mail addresses
+			`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`,               // Bearer tokens
+		},
+		SQLLiterals: true,
+		Replacement: DefaultReplacement,
+	}
+}
+
+// LoadRules reads a JSON rules file on top of DefaultRules: fields present in the file replace
+// the defaults, omitted fields keep them.
+func LoadRules(path string) (Rules, error) {
+	rules := DefaultRules()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Rules{}, fmt.Errorf("failed to read redaction rules file: %w", err)
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return Rules{}, fmt.Errorf("failed to parse redaction rules file %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// Redactor applies Rules to strings, URLs, headers, SQL statements and attributes.
+// A nil *Redactor leaves every value unchanged.
+type Redactor struct {
+	queryParams map[string]struct{}
+	headers     map[string]struct{}
+	patterns    []*regexp.Regexp
+	sqlLiterals bool
+	replacement string
+}
+
+// sqlLiteral matches single quoted string literals (with ” escapes) and numeric literals.
+var sqlLiteral = regexp.MustCompile(`'(?:[^']|'')*'|\b\d+(?:\.\d+)?\b`)
+
+// New compiles rules into a Redactor.
+//
+// Example usage:
+//
+//	redactor, err := redact.New(redact.DefaultRules())
+//	if err != nil {
+//	    logger.Fatal("invalid redaction rules", zap.Error(err))
+//	}
+//	logger.Info("request", zap.String("url", redactor.URL(r.URL.String())))
+func New(rules Rules) (*Redactor, error) {
+	r := &Redactor{
+		queryParams: lowerSet(rules.QueryParams),
+		headers:     lowerSet(rules.Headers),
+		sqlLiterals: rules.SQLLiterals,
+		replacement: rules.Replacement,
+	}
+	if r.replacement == "" {
+		r.replacement = DefaultReplacement
+	}
+	for _, pattern := range rules.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Default returns a Redactor applying DefaultRules.
+func Default() *Redactor {
+	r, err := New(DefaultRules())
+	if err != nil {
+		// The default patterns are constant and known to compile
+		panic(err)
+	}
+	return r
+}
+
+// String redacts the matches of the configured patterns in s.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllLiteralString(s, r.replacement)
+	}
+	return s
+}
+
+// URL redacts the values of the configured query parameters in a URL, request target
+// ("/path?query") or bare query string, then applies the patterns.
+func (r *Redactor) URL(s string) string {
+	if r == nil {
+		return s
+	}
+	prefix, query, found := strings.Cut(s, "?")
+	if !found {
+		// A bare query string ("a=1&b=2") has no path part
+		if strings.Contains(s, "=") && !strings.Contains(s, "/") {
+			prefix, query = "", s
+		} else {
+			return r.String(s)
+		}
+	}
+
+	fragment := ""
+	if i := strings.IndexByte(query, '#'); i >= 0 {
+		query, fragment = query[:i], query[i:]
+	}
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		name, _, hasValue := strings.Cut(param, "=")
+		if !hasValue {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if _, ok := r.queryParams[strings.ToLower(name)]; ok {
+			params[i] = param[:strings.IndexByte(param, '=')+1] + r.replacement
+		}
+	}
+
+	query = strings.Join(params, "&") + fragment
+	if found {
+		return r.String(prefix + "?" + query)
+	}
+	return r.String(query)
+}
+
+// Header redacts the value of a header whose name is configured, otherwise applies the patterns.
+func (r *Redactor) Header(name, value string) string {
+	if r == nil {
+		return value
+	}
+	if _, ok := r.headers[strings.ToLower(name)]; ok {
+		return r.replacement
+	}
+	return r.String(value)
+}
+
+// SQL replaces the literals of a SQL statement with "?" when enabled, then applies the patterns.
+func (r *Redactor) SQL(statement string) string {
+	if r == nil {
+		return statement
+	}
+	if r.sqlLiterals {
+		statement = sqlLiteral.ReplaceAllLiteralString(statement, "?")
+	}
+	return r.String(statement)
+}
+
+// Attribute redacts a string or string slice attribute according to its key: SQL for
+// db.statement and db.query.text, URL rules for http.target, http.url, url.full and url.query,
+// header rules for http.request.header.* and http.response.header.*, and the patterns for any
+// other key. The elements of a string slice are redacted one by one.
+func (r *Redactor) Attribute(kv attribute.KeyValue) attribute.KeyValue {
+	if r == nil {
+		return kv
+	}
+	key := string(kv.Key)
+	switch kv.Value.Type() {
+	case attribute.STRING:
+		return attribute.String(key, r.attributeValue(key, kv.Value.AsString()))
+	case attribute.STRINGSLICE:
+		values := kv.Value.AsStringSlice()
+		for i, value := range values {
+			values[i] = r.attributeValue(key, value)
+		}
+		return attribute.StringSlice(key, values)
+	}
+	return kv
+}
+
+// attributeValue redacts one string value of the key attribute.
+func (r *Redactor) attributeValue(key, value string) string {
+	switch {
+	case key == "db.statement" || key == "db.query.text":
+		return r.SQL(value)
+	case key == "http.target" || key == "http.url" || key == "url.full" || key == "url.query":
+		return r.URL(value)
+	case strings.HasPrefix(key, "http.request.header."):
+		return r.Header(strings.TrimPrefix(key, "http.request.header."), value)
+	case strings.HasPrefix(key, "http.response.header."):
+		return r.Header(strings.TrimPrefix(key, "http.response.header."), value)
+	default:
+		return r.String(value)
+	}
+}
+
+// Attributes returns a copy of attrs with every attribute redacted by Attribute.
+func (r *Redactor) Attributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	if r == nil || len(attrs) == 0 {
+		return attrs
+	}
+	redacted := make([]attribute.KeyValue, len(attrs))
+	for i, kv := range attrs {
+		redacted[i] = r.Attribute(kv)
+	}
+	return redacted
+}
+
+// lowerSet builds a set of the lower-cased names.
+func lowerSet(names []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[strings.ToLower(name)] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,128 @@
+package redact
+
+import (
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestRedactorURL(t *testing.T) {
+	r := Default()
+	tests := []struct {
+		name, input, want string
+	}{
+		{"path only", "/orders/42", "/orders/42"},
+		{"request target", "/login?user=bob&password=hunter2", "/login?user=bob&password=[REDACTED]"},
+		{"full URL", "https://api.test/cb?code=abc&state=xyz", "https://api.test/cb?code=[REDACTED]&state=xyz"},
+		{"case-insensitive name", "/cb?Access_Token=abc", "/cb?Access_Token=[REDACTED]"},
+		{"escaped name", "/cb?api%5Fkey=abc", "/cb?api%5Fkey=[REDACTED]"},
+		{"bare query", "token=abc&page=2", "token=[REDACTED]&page=2"},
+		{"fragment kept", "/cb?token=abc#top", "/cb?token=[REDACTED]#top"},
+		{"parameter without value", "/search?token&q=go", "/search?token&q=go"},
+		{"pattern in value", "/invite?email=bob@example.com", "/invite?email=[REDACTED]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.URL(tt.input); got != tt.want {
+				t.Errorf("URL(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorHeader(t *testing.T) {
+	r := Default()
+	tests := []struct {
+		name, header, value, want string
+	}{
+		{"configured header", "Authorization", "Basic dXNlcjpwYXNz", "[REDACTED]"},
+		{"case-insensitive name", "COOKIE", "session=abc", "[REDACTED]"},
+		{"other header", "Accept", "application/json", "application/json"},
+		{"pattern in other header", "X-Forwarded-Authorization", "Bearer abc.def", "[REDACTED]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Header(tt.header, tt.value); got != tt.want {
+				t.Errorf("Header(%q, %q) = %q, want %q", tt.header, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorAttribute(t *testing.T) {
+	r := Default()
+	tests := []struct {
+		name  string
+		input attribute.KeyValue
+		want  attribute.KeyValue
+	}{
+		{
+			"SQL statement",
+			attribute.String("db.statement", "SELECT * FROM users WHERE name = 'bob' AND age > 30"),
+			attribute.String("db.statement", "SELECT * FROM users WHERE name = ? AND age > ?"),
+		},
+		{
+			"URL",
+			attribute.String("url.full", "https://api.test/cb?token=abc"),
+			attribute.String("url.full", "https://api.test/cb?token=[REDACTED]"),
+		},
+		{
+			"request header",
+			attribute.String("http.request.header.authorization", "Basic dXNlcjpwYXNz"),
+			attribute.String("http.request.header.authorization", "[REDACTED]"),
+		},
+		{
+			"header slice",
+			attribute.StringSlice("http.response.header.set-cookie", []string{"a=1", "b=2"}),
+			attribute.StringSlice("http.response.header.set-cookie", []string{"[REDACTED]", "[REDACTED]"}),
+		},
+		{
+			"string slice",
+			attribute.StringSlice("user.emails", []string{"bob@example.com", "none"}),
+			attribute.StringSlice("user.emails", []string{"[REDACTED]", "none"}),
+		},
+		{
+			"pattern",
+			attribute.String("user.email", "bob@example.com"),
+			attribute.String("user.email", "[REDACTED]"),
+		},
+		{
+			"non-string value",
+			attribute.Int("http.response.status_code", 200),
+			attribute.Int("http.response.status_code", 200),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Attribute(tt.input); got != tt.want {
+				t.Errorf("Attribute(%s=%s) = %s, want %s", tt.input.Key, tt.input.Value.Emit(), got.Value.Emit(), tt.want.Value.Emit())
+			}
+		})
+	}
+}
+
+func TestRedactorAttributeSliceNotModified(t *testing.T) {
+	input := attribute.StringSlice("user.emails", []string{"bob@example.com"})
+	Default().Attribute(input)
+	if got := input.Value.AsStringSlice(); !reflect.DeepEqual(got, []string{"bob@example.com"}) {
+		t.Errorf("input attribute changed to %v", got)
+	}
+}
+
+func TestNilRedactor(t *testing.T) {
+	var r *Redactor
+	kv := attribute.String("user.email", "bob@example.com")
+	if got := r.Attribute(kv); got != kv {
+		t.Errorf("Attribute() = %v, want it unchanged", got)
+	}
+	if got := r.URL("/cb?token=abc"); got != "/cb?token=abc" {
+		t.Errorf("URL() = %q, want it unchanged", got)
+	}
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	if _, err := New(Rules{Patterns: []string{"("}}); err == nil {
+		t.Error("New() succeeded with an invalid pattern, want an error")
+	}
+}
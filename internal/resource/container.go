@@ -0,0 +1,62 @@
+package resource
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"regexp"
+	"strings"
+
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Paths inspected for the container ID.
+var (
+	cgroupPath    = "/proc/self/cgroup"    // cgroup v1: one line per hierarchy, ending in the container ID
+	mountinfoPath = "/proc/self/mountinfo" // cgroup v2: container runtimes bind mount files from the container directory
+)
+
+var (
+	// cgroupContainerIDRe matches the trailing 64 hex digit ID in cgroup v1 paths such as
+	// "/docker/<id>", "/kubepods/burstable/pod<uid>/<id>" or "/system.slice/docker-<id>.scope".
+	cgroupContainerIDRe = regexp.MustCompile(`([0-9a-f]{64})(?:\.scope)?\s*$`)
+	// mountinfoContainerIDRe matches container directories in mountinfo entries, e.g.
+	// "/var/lib/docker/containers/<id>/hostname" or "/sandboxes/<id>/hosts".
+	mountinfoContainerIDRe = regexp.MustCompile(`/(?:containers|sandboxes|overlay-containers)/([0-9a-f]{64})/`)
+)
+
+// containerDetector sets container.id from the cgroup files of the current process, supporting
+// both cgroup v1 and the cgroup v2 layout where /proc/self/cgroup only contains "0::/".
+type containerDetector struct{}
+
+// Detect implements resource.Detector.
+func (containerDetector) Detect(context.Context) (*sdkresource.Resource, error) {
+	id := containerIDFromFile(cgroupPath, cgroupContainerIDRe)
+	if id == "" {
+		id = containerIDFromFile(mountinfoPath, mountinfoContainerIDRe)
+	}
+	if id == "" {
+		// Not running in a container
+		return sdkresource.Empty(), nil
+	}
+	return sdkresource.NewWithAttributes(semconv.SchemaURL, semconv.ContainerID(id)), nil
+}
+
+// containerIDFromFile returns the first container ID matched by re in the file at path.
+func containerIDFromFile(path string, re *regexp.Regexp) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := re.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
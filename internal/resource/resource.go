@@ -0,0 +1,105 @@
+// Package resource builds the OpenTelemetry resource shared by traces, metrics and logs, so
+// that every signal describes the service with the same, complete set of attributes.
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Attribute keys for build information not covered by the semantic conventions.
+const (
+	vcsRevisionKey = attribute.Key("vcs.revision")
+	vcsTimeKey     = attribute.Key("vcs.time")
+	vcsModifiedKey = attribute.Key("vcs.modified")
+)
+
+// Config holds the service identity attached to the resource.
+type Config struct {
+	ServiceName    string // service.name, overridden by OTEL_SERVICE_NAME
+	ServiceVersion string // service.version; falls back to the module version from the build info
+	Environment    string // deployment.environment (e.g. "production"), omitted when empty
+}
+
+// New builds the resource describing this process. Attributes are merged in increasing order
+// of precedence:
+//  1. build information (service.version and VCS revision from debug.ReadBuildInfo)
+//  2. the service identity from cfg
+//  3. detected host, OS, process, container and telemetry SDK attributes
+//  4. OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME
+//
+// Detector failures for optional attributes (e.g. no container ID outside a container) are
+// ignored; the partial resource is still returned.
+//
+// Example usage:
+//
+//	res, err := New(ctx, Config{ServiceName: "my-app", Environment: "production"})
+//	if err != nil {
+//	    log.Fatalf("failed to create resource: %v", err)
+//	}
+//	tp, err := tracing.InitTracer("localhost:4317", "my-app", tracing.WithResource(res))
+func New(ctx context.Context, cfg Config) (*sdkresource.Resource, error) {
+	attrs := buildInfoAttributes()
+	if cfg.ServiceName != "" {
+		attrs = append(attrs, semconv.ServiceName(cfg.ServiceName))
+	}
+	if cfg.ServiceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersion(cfg.ServiceVersion))
+	}
+	if cfg.Environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironment(cfg.Environment))
+	}
+
+	res, err := sdkresource.New(ctx,
+		sdkresource.WithSchemaURL(semconv.SchemaURL),
+		sdkresource.WithAttributes(attrs...),
+		sdkresource.WithTelemetrySDK(),
+		sdkresource.WithHost(),
+		sdkresource.WithOS(),
+		sdkresource.WithProcess(),
+		sdkresource.WithDetectors(containerDetector{}),
+		sdkresource.WithFromEnv(), // OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME win
+	)
+	if err != nil {
+		// Partial resources are usable; only fail when nothing could be built
+		if res == nil || !isPartial(err) {
+			return nil, fmt.Errorf("failed to create resource: %w", err)
+		}
+	}
+	return res, nil
+}
+
+// isPartial reports whether err only signals that some detectors failed.
+func isPartial(err error) bool {
+	return errors.Is(err, sdkresource.ErrPartialResource)
+}
+
+// buildInfoAttributes returns service.version and VCS attributes stamped by the Go toolchain.
+func buildInfoAttributes() []attribute.KeyValue {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	var attrs []attribute.KeyValue
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		attrs = append(attrs, semconv.ServiceVersion(v))
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			attrs = append(attrs, vcsRevisionKey.String(setting.Value))
+		case "vcs.time":
+			attrs = append(attrs, vcsTimeKey.String(setting.Value))
+		case "vcs.modified":
+			attrs = append(attrs, vcsModifiedKey.Bool(setting.Value == "true"))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,266 @@
+// Package telemetry wires the tracing, metrics and logging packages together behind a single
+// Setup call and a single, bounded Shutdown.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"opentelemetry-api/internal/httpconv"
+	"opentelemetry-api/internal/logging"
+	"opentelemetry-api/internal/metrics"
+	"opentelemetry-api/internal/otlpconfig"
+	"opentelemetry-api/internal/redact"
+	"opentelemetry-api/internal/resource"
+	"opentelemetry-api/internal/tracing"
+
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+)
+
+// defaultAdminAddr is the admin listener address used when Config.AdminAddr is unset.
+// 9464 is the port conventionally used by OpenTelemetry Prometheus exporters.
+const defaultAdminAddr = ":9464"
+
+// defaultShutdownTimeout bounds Shutdown and ForceFlush when Config.ShutdownTimeout is unset.
+const defaultShutdownTimeout = 5 * time.Second
+
+// Config describes the telemetry pipeline built by Setup.
+type Config struct {
+	Endpoint            string                   // OTLP endpoint shared by all signals (e.g. "localhost:4317")
+	Resource            resource.Config          // Service identity; Resource.ServiceName also names the meter
+	TLS                 otlpconfig.TLSConfig     // Transport security for all OTLP exporters
+	Headers             map[string]string        // Extra headers sent with every OTLP export
+	Token               *otlpconfig.TokenFile    // Bearer token sent with every OTLP export, nil when unset
+	RequestCounterName  string                   // Name of the HTTP request counter
+	RequestDurationName string                   // Name of the HTTP request duration histogram
+	HTTPSemconv         httpconv.Mode            // Legacy, stable or duplicated HTTP metric names
+	Redactor            *redact.Redactor         // Redacts span attributes and custom metric attributes, nil for none
+	AttributeLimits     *metrics.AttributeLimits // Cardinality limits for custom HTTP metric attributes, nil for none
+	Tracing             []tracing.Option         // Additional tracing options (exporter, sampler, ...)
+	Metrics             []metrics.Option         // Additional metrics options
+	Logging             []logging.Option         // Additional logging options (exporter, ...)
+	MetricsExporters    []metrics.ExporterKind   // Metric exporters to enable; nil means OTLP only
+	AdminAddr           string                   // Address of the admin listener serving /metrics for Prometheus (default ":9464")
+	ShutdownTimeout     time.Duration            // Upper bound for Shutdown and ForceFlush (default 5s)
+	Logger              *zap.Logger              // Logger for setup diagnostics
+}
+
+// component is one signal pipeline managed by Telemetry.
+type component struct {
+	name       string
+	forceFlush func(context.Context) error
+	shutdown   func(context.Context) error
+}
+
+// Telemetry is the handle returned by Setup. It owns every provider it created.
+type Telemetry struct {
+	Resource       *sdkresource.Resource      // Resource shared by all signals
+	TracerProvider *sdktrace.TracerProvider   // Also installed as the global TracerProvider
+	MeterProvider  *sdkmetric.MeterProvider   // Also installed as the global MeterProvider
+	LoggerProvider *sdklog.LoggerProvider     // Also installed as the global LoggerProvider; bridge zap with logging.NewCore
+	HTTPMetrics    *metrics.HTTPServerMetrics // HTTP server instruments for middleware.MetricsMiddleware
+	AdminServer    *http.Server               // Serves /metrics when the Prometheus exporter is enabled, otherwise nil
+
+	components []component // In shutdown order
+	timeout    time.Duration
+}
+
+// Setup builds the shared resource and initializes metrics, tracing and logs with the common exporter
+// settings from cfg. If any signal fails to initialize, the ones already started are shut down,
+// bounded by the shutdown timeout even when ctx has no deadline, before the error is returned.
+//
+// Example usage:
+//
+//	tel, err := Setup(ctx, Config{Endpoint: "localhost:4317", Resource: resource.Config{ServiceName: "my-app"}, Logger: logger})
+//	if err != nil {
+//	    logger.Fatal("failed to initialize telemetry", zap.Error(err))
+//	}
+//	defer tel.Shutdown(context.Background())
+func Setup(ctx context.Context, cfg Config) (*Telemetry, error) {
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	timeout := cfg.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	// Build the resource once so that every signal describes the service identically
+	res, err := resource.New(ctx, cfg.Resource)
+	if err != nil {
+		return nil, err
+	}
+	t := &Telemetry{Resource: res, timeout: timeout}
+
+	exporters := cfg.MetricsExporters
+	if exporters == nil {
+		exporters = []metrics.ExporterKind{metrics.ExporterOTLP}
+	}
+	metricsOpts := []metrics.Option{
+		metrics.WithTLS(cfg.TLS),
+		metrics.WithHeaders(cfg.Headers),
+		metrics.WithTokenFile(cfg.Token),
+		metrics.WithResource(res),
+		metrics.WithOTLP(slices.Contains(exporters, metrics.ExporterOTLP)),
+		metrics.WithHTTPSemconv(cfg.HTTPSemconv),
+	}
+	// The Prometheus reader is pull based: its handler is served on the admin listener below
+	var (
+		promReader  sdkmetric.Reader
+		promHandler http.Handler
+	)
+	if slices.Contains(exporters, metrics.ExporterPrometheus) {
+		promReader, promHandler, err = metrics.NewPrometheusReader()
+		if err != nil {
+			return nil, err
+		}
+		metricsOpts = append(metricsOpts, metrics.WithReader(promReader))
+	}
+
+	mp, err := metrics.InitMetrics(
+		cfg.Endpoint,
+		cfg.Resource.ServiceName,
+		cfg.RequestCounterName,
+		cfg.RequestDurationName,
+		cfg.Logger,
+		append(metricsOpts, cfg.Metrics...)...,
+	)
+	if err != nil {
+		err = fmt.Errorf("failed to initialize metrics: %w", err)
+		if promReader != nil {
+			// Release the reader, which no MeterProvider owns (or one that is already shut down)
+			err = errors.Join(err, t.shutdownReader(ctx, promReader))
+		}
+		return nil, err
+	}
+	t.MeterProvider = mp
+	// Components are kept in shutdown order as the signals start, so that a failure can stop them
+	t.components = []component{{name: "metrics", forceFlush: mp.ForceFlush, shutdown: mp.Shutdown}}
+
+	httpOpts := []metrics.HTTPServerMetricsOption{
+		metrics.WithSemconvMode(cfg.HTTPSemconv),
+		metrics.WithRedactor(cfg.Redactor),
+	}
+	if cfg.AttributeLimits != nil {
+		httpOpts = append(httpOpts, metrics.WithAttributeLimits(*cfg.AttributeLimits))
+	}
+	t.HTTPMetrics, err = metrics.NewHTTPServerMetrics(
+		mp,
+		cfg.Resource.ServiceName,
+		cfg.RequestCounterName,
+		cfg.RequestDurationName,
+		httpOpts...,
+	)
+	if err != nil {
+		return nil, t.abort(ctx, fmt.Errorf("failed to create HTTP server metrics: %w", err))
+	}
+
+	tp, err := tracing.InitTracer(
+		cfg.Endpoint,
+		cfg.Resource.ServiceName,
+		append([]tracing.Option{
+			tracing.WithTLS(cfg.TLS),
+			tracing.WithHeaders(cfg.Headers),
+			tracing.WithTokenFile(cfg.Token),
+			tracing.WithResource(res),
+			tracing.WithRedactor(cfg.Redactor),
+		}, cfg.Tracing...)...,
+	)
+	if err != nil {
+		return nil, t.abort(ctx, fmt.Errorf("failed to initialize tracing: %w", err))
+	}
+	t.TracerProvider = tp
+	// Traces are shut down first: ending spans may still record metrics (e.g. tail sampling)
+	t.components = append([]component{{name: "traces", forceFlush: tp.ForceFlush, shutdown: tp.Shutdown}}, t.components...)
+
+	lp, err := logging.InitLogger(
+		cfg.Endpoint,
+		cfg.Resource.ServiceName,
+		append([]logging.Option{
+			logging.WithTLS(cfg.TLS),
+			logging.WithHeaders(cfg.Headers),
+			logging.WithTokenFile(cfg.Token),
+			logging.WithResource(res),
+		}, cfg.Logging...)...,
+	)
+	if err != nil {
+		return nil, t.abort(ctx, fmt.Errorf("failed to initialize logs: %w", err))
+	}
+	t.LoggerProvider = lp
+	// Logs go last so that problems shutting down the other signals are still exported
+	t.components = append(t.components, component{name: "logs", forceFlush: lp.ForceFlush, shutdown: lp.Shutdown})
+
+	if promHandler != nil {
+		addr := cfg.AdminAddr
+		if addr == "" {
+			addr = defaultAdminAddr
+		}
+		t.AdminServer, err = metrics.ServeAdmin(addr, promHandler, cfg.Logger)
+		if err != nil {
+			return nil, t.abort(ctx, err)
+		}
+		// Stop serving scrapes before the MeterProvider they read from is shut down
+		t.components = append([]component{{
+			name:       "admin server",
+			forceFlush: func(context.Context) error { return nil },
+			shutdown:   t.AdminServer.Shutdown,
+		}}, t.components...)
+	}
+	return t, nil
+}
+
+// abort shuts down the signals started so far after Setup failed with err, bounded by the
+// shutdown timeout. ctx only provides values: Setup may fail precisely because it was canceled.
+func (t *Telemetry) abort(ctx context.Context, err error) error {
+	return errors.Join(err, t.Shutdown(context.WithoutCancel(ctx)))
+}
+
+// shutdownReader shuts down a reader Setup created, bounded by the shutdown timeout. A reader
+// that is already shut down, e.g. with the MeterProvider it was registered with, is not an error.
+func (t *Telemetry) shutdownReader(ctx context.Context, reader sdkmetric.Reader) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), t.timeout)
+	defer cancel()
+	if err := reader.Shutdown(ctx); err != nil && !errors.Is(err, sdkmetric.ErrReaderShutdown) {
+		return fmt.Errorf("failed to shutdown Prometheus reader: %w", err)
+	}
+	return nil
+}
+
+// ForceFlush exports all buffered telemetry, bounded by the configured shutdown timeout.
+// Errors from every signal are combined.
+func (t *Telemetry) ForceFlush(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	var errs []error
+	for _, c := range t.components {
+		if err := c.forceFlush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush %s: %w", c.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown flushes and stops every signal in order (admin server, traces, metrics, then logs), bounded by the
+// configured shutdown timeout so that an unreachable collector cannot hang the process.
+// Every signal is shut down even if an earlier one fails; the errors are combined.
+func (t *Telemetry) Shutdown(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	var errs []error
+	for _, c := range t.components {
+		if err := c.shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shutdown %s: %w", c.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
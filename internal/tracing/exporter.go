@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"opentelemetry-api/internal/otlpconfig"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/exporters/zipkin"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ExporterKind identifies the backend InitTracer ships spans to.
+type ExporterKind string
+
+const (
+	ExporterOTLPGRPC ExporterKind = "otlp-grpc" // OTLP over gRPC (default)
+	ExporterOTLPHTTP ExporterKind = "otlp-http" // OTLP over HTTP with protobuf payloads
+	ExporterZipkin   ExporterKind = "zipkin"    // Zipkin v2 JSON API
+	ExporterStdout   ExporterKind = "stdout"    // Pretty-printed spans on stdout, for local debugging
+	ExporterNone     ExporterKind = "none"      // Spans are created but never exported
+)
+
+// ParseExporterKind maps the standard OTEL_TRACES_EXPORTER and
+// OTEL_EXPORTER_OTLP_PROTOCOL values to an ExporterKind.
+//
+// Supported exporters are "otlp", "zipkin", "console" (or "stdout") and "none".
+// For "otlp" the protocol selects the transport: "grpc" (the default when empty)
+// or "http/protobuf".
+//
+// Example usage:
+//
+//	kind, err := ParseExporterKind(os.Getenv("OTEL_TRACES_EXPORTER"), os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"))
+//	if err != nil {
+//	    log.Fatalf("invalid trace exporter configuration: %v", err)
+//	}
+func ParseExporterKind(exporter, protocol string) (ExporterKind, error) {
+	switch strings.ToLower(strings.TrimSpace(exporter)) {
+	case "", "otlp":
+		switch strings.ToLower(strings.TrimSpace(protocol)) {
+		case "", "grpc":
+			return ExporterOTLPGRPC, nil
+		case "http/protobuf":
+			return ExporterOTLPHTTP, nil
+		default:
+			return "", fmt.Errorf("unsupported OTLP protocol %q (expected \"grpc\" or \"http/protobuf\")", protocol)
+		}
+	case "zipkin":
+		return ExporterZipkin, nil
+	case "console", "stdout":
+		return ExporterStdout, nil
+	case "none":
+		return ExporterNone, nil
+	default:
+		return "", fmt.Errorf("unsupported trace exporter %q (expected one of otlp, zipkin, console, none)", exporter)
+	}
+}
+
+// newExporter builds the SpanExporter selected by cfg. It returns a nil exporter
+// for ExporterNone so that the caller can skip registering a span processor.
+// The TLS settings are only loaded by the exporters connecting to a collector.
+func newExporter(ctx context.Context, cfg config, endpoint string) (trace.SpanExporter, error) {
+	switch cfg.exporter {
+	case ExporterOTLPGRPC:
+		transport, err := otlpconfig.NewGRPCTransport(cfg.tls, cfg.token)
+		if err != nil {
+			return nil, err
+		}
+		opts := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(endpoint),
+			otlptracegrpc.WithHeaders(cfg.headers),
+			otlptracegrpc.WithDialOption(transport.DialOptions...),
+		}
+		if transport.Credentials == nil {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		} else {
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(transport.Credentials))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		if err := cfg.token.CheckTransport(cfg.tls); err != nil {
+			return nil, err
+		}
+		tlsCfg, err := cfg.tls.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		// The HTTP exporter only accepts static headers, so the token is read once here
+		headers, err := otlpconfig.MergeHeaders(cfg.headers, cfg.token)
+		if err != nil {
+			return nil, err
+		}
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(endpoint),
+			otlptracehttp.WithHeaders(headers),
+		}
+		if tlsCfg == nil {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		} else {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsCfg))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ExporterZipkin:
+		tlsCfg, err := cfg.tls.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		var opts []zipkin.Option
+		if tlsCfg != nil {
+			opts = append(opts, zipkin.WithClient(&http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}}))
+		}
+		// An empty URL makes the exporter fall back to OTEL_EXPORTER_ZIPKIN_ENDPOINT
+		// or http://localhost:9411/api/v2/spans.
+		return zipkin.New("", opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case ExporterNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter kind %q", cfg.exporter)
+	}
+}
@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"opentelemetry-api/internal/otlpconfig"
+	"opentelemetry-api/internal/redact"
+
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// config holds the optional settings applied by InitTracer.
+type config struct {
+	exporter   ExporterKind                  // Which exporter spans are shipped to
+	sampler    trace.Sampler                 // Head sampler deciding which traces are recorded
+	tail       *TailSamplingConfig           // Tail sampling settings, nil when tail sampling is disabled
+	tls        otlpconfig.TLSConfig          // Transport security for the OTLP exporters
+	headers    map[string]string             // Extra headers sent with every OTLP export
+	token      *otlpconfig.TokenFile         // Bearer token attached to every OTLP export, nil when unset
+	resource   *sdkresource.Resource         // Resource describing the service, nil to detect one
+	redactor   *redact.Redactor              // Redacts span attributes before export, nil to export them as is
+	processors []trace.SpanProcessor         // Additional processors registered alongside the exporter
+	propagator propagation.TextMapPropagator // Installed as the global propagator
+}
+
+// newConfig returns the default configuration with opts applied on top.
+func newConfig(opts ...Option) config {
+	cfg := config{
+		exporter:   ExporterOTLPGRPC,
+		sampler:    trace.AlwaysSample(),
+		tls:        otlpconfig.TLSConfig{Insecure: true},
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// Option customizes the TracerProvider built by InitTracer.
+type Option func(*config)
+
+// WithExporter selects the span exporter. Defaults to ExporterOTLPGRPC.
+func WithExporter(kind ExporterKind) Option {
+	return func(c *config) {
+		c.exporter = kind
+	}
+}
+
+// WithSampler sets the head sampler, e.g. one built by NewSampler. Defaults to trace.AlwaysSample().
+func WithSampler(sampler trace.Sampler) Option {
+	return func(c *config) {
+		c.sampler = sampler
+	}
+}
+
+// WithTailSampling buffers spans in a TailSamplingProcessor in front of the batcher so that only
+// errored, slow or probabilistically kept traces are exported.
+func WithTailSampling(cfg TailSamplingConfig) Option {
+	return func(c *config) {
+		c.tail = &cfg
+	}
+}
+
+// WithTLS configures transport security for the OTLP and Zipkin exporters.
+// Defaults to an insecure (plaintext) connection.
+func WithTLS(tls otlpconfig.TLSConfig) Option {
+	return func(c *config) {
+		c.tls = tls
+	}
+}
+
+// WithHeaders adds headers, e.g. parsed by otlpconfig.ParseHeaders, to every OTLP trace exporter call.
+func WithHeaders(headers map[string]string) Option {
+	return func(c *config) {
+		c.headers = headers
+	}
+}
+
+// WithTokenFile sends the token from token as an "authorization: Bearer" header with every
+// OTLP trace exporter call. Over gRPC the file is re-read when it changes.
+func WithTokenFile(token *otlpconfig.TokenFile) Option {
+	return func(c *config) {
+		c.token = token
+	}
+}
+
+// WithResource sets the resource recorded on every span, typically the one shared with the
+// metrics and logs so that all signals describe the same service instance. By default
+// InitTracer detects a resource for its serviceName.
+func WithResource(res *sdkresource.Resource) Option {
+	return func(c *config) {
+		c.resource = res
+	}
+}
+
+// WithRedactor redacts span attributes, event attributes and status descriptions (e.g. SQL
+// literals in db.statement, tokens in http.target) before spans are exported.
+func WithRedactor(redactor *redact.Redactor) Option {
+	return func(c *config) {
+		c.redactor = redactor
+	}
+}
+
+// WithSpanProcessor registers an additional span processor on the TracerProvider, e.g. a
+// middleware.ServerTimingRecorder. It sees every sampled span, whichever exporter is selected.
+func WithSpanProcessor(processor trace.SpanProcessor) Option {
+	return func(c *config) {
+		c.processors = append(c.processors, processor)
+	}
+}
+
+// WithPropagator sets the propagator installed globally by InitTracer, e.g. one built by
+// NewPropagator. Defaults to W3C TraceContext and Baggage.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/contrib/propagators/aws/xray"
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// defaultPropagators are the propagators used when OTEL_PROPAGATORS is empty.
+const defaultPropagators = "tracecontext,baggage"
+
+// NewPropagator builds the composite propagator described by the standard OTEL_PROPAGATORS
+// value, a comma separated list of propagator names. Context is injected in every listed
+// format, and extracted from each of them in order, later formats taking precedence when an
+// incoming request carries several.
+//
+// Supported propagators:
+//   - tracecontext: W3C traceparent and tracestate headers
+//   - baggage: W3C baggage header
+//   - b3: B3 single "b3" header (both B3 encodings are accepted when extracting)
+//   - b3multi: B3 multi X-B3-TraceId, X-B3-SpanId and X-B3-Sampled headers
+//   - jaeger: Jaeger uber-trace-id header
+//   - xray: AWS X-Ray X-Amzn-Trace-Id header
+//   - none: no propagation, only valid on its own
+//
+// An empty value selects "tracecontext,baggage".
+//
+// Example usage:
+//
+//	propagator, err := NewPropagator(os.Getenv("OTEL_PROPAGATORS"))
+//	if err != nil {
+//	    log.Fatalf("invalid propagator configuration: %v", err)
+//	}
+//	tp, err := InitTracer("localhost:4317", "my-app", WithPropagator(propagator))
+func NewPropagator(value string) (propagation.TextMapPropagator, error) {
+	if strings.TrimSpace(value) == "" {
+		value = defaultPropagators
+	}
+
+	var propagators []propagation.TextMapPropagator
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		switch name {
+		case "tracecontext":
+			propagators = append(propagators, propagation.TraceContext{})
+		case "baggage":
+			propagators = append(propagators, propagation.Baggage{})
+		case "b3":
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case "b3multi":
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case "jaeger":
+			propagators = append(propagators, jaeger.Jaeger{})
+		case "xray":
+			propagators = append(propagators, xray.Propagator{})
+		case "none":
+		default:
+			return nil, fmt.Errorf("unsupported propagator %q (expected one of tracecontext, baggage, b3, b3multi, jaeger, xray, none)", name)
+		}
+	}
+	if seen["none"] && len(seen) > 1 {
+		return nil, fmt.Errorf("propagator \"none\" cannot be combined with other propagators")
+	}
+	return propagation.NewCompositeTextMapPropagator(propagators...), nil
+}
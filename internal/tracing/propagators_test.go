@@ -0,0 +1,146 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	testTraceID = trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	testSpanID  = trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7}
+)
+
+func testSpanContext() trace.SpanContext {
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    testTraceID,
+		SpanID:     testSpanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+}
+
+func TestNewPropagatorRoundTrip(t *testing.T) {
+	tests := []struct {
+		value      string
+		wantHeader string
+	}{
+		{value: "", wantHeader: "Traceparent"},
+		{value: "tracecontext", wantHeader: "Traceparent"},
+		{value: "b3", wantHeader: "B3"},
+		{value: "b3multi", wantHeader: "X-B3-Traceid"},
+		{value: "jaeger", wantHeader: "Uber-Trace-Id"},
+		{value: "xray", wantHeader: "X-Amzn-Trace-Id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			propagator, err := NewPropagator(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			header := http.Header{}
+			ctx := trace.ContextWithSpanContext(context.Background(), testSpanContext())
+			propagator.Inject(ctx, propagation.HeaderCarrier(header))
+			if header.Get(tt.wantHeader) == "" {
+				t.Fatalf("injected headers %v lack %s", header, tt.wantHeader)
+			}
+
+			got := trace.SpanContextFromContext(propagator.Extract(context.Background(), propagation.HeaderCarrier(header)))
+			if !got.Equal(testSpanContext()) {
+				t.Errorf("extracted %v, want %v", got, testSpanContext())
+			}
+		})
+	}
+}
+
+func TestNewPropagatorExtractsUpstreamHeaders(t *testing.T) {
+	tests := []struct {
+		value  string
+		header string
+		input  string
+	}{
+		{value: "b3", header: "b3", input: "4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1"},
+		{value: "jaeger", header: "uber-trace-id", input: "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1"},
+		{value: "xray", header: "X-Amzn-Trace-Id", input: "Root=1-4bf92f35-77b34da6a3ce929d0e0e4736;Parent=00f067aa0ba902b7;Sampled=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			// Every format is accepted alongside the W3C defaults
+			propagator, err := NewPropagator("tracecontext,baggage," + tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			header := http.Header{}
+			header.Set(tt.header, tt.input)
+
+			got := trace.SpanContextFromContext(propagator.Extract(context.Background(), propagation.HeaderCarrier(header)))
+			if !got.Equal(testSpanContext()) {
+				t.Errorf("extracted %v, want %v", got, testSpanContext())
+			}
+		})
+	}
+}
+
+func TestNewPropagatorB3MultiHeaders(t *testing.T) {
+	propagator, err := NewPropagator("b3multi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{}
+	header.Set("X-B3-TraceId", testTraceID.String())
+	header.Set("X-B3-SpanId", testSpanID.String())
+	header.Set("X-B3-Sampled", "1")
+
+	got := trace.SpanContextFromContext(propagator.Extract(context.Background(), propagation.HeaderCarrier(header)))
+	if !got.Equal(testSpanContext()) {
+		t.Errorf("extracted %v, want %v", got, testSpanContext())
+	}
+}
+
+func TestNewPropagatorBaggage(t *testing.T) {
+	propagator, err := NewPropagator("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	member, err := baggage.NewMember("request.id", "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bag, err := baggage.New(member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := http.Header{}
+	propagator.Inject(baggage.ContextWithBaggage(context.Background(), bag), propagation.HeaderCarrier(header))
+	got := baggage.FromContext(propagator.Extract(context.Background(), propagation.HeaderCarrier(header)))
+	if got.Member("request.id").Value() != "abc" {
+		t.Errorf("extracted baggage %q, want request.id=abc", got.String())
+	}
+}
+
+func TestNewPropagatorNone(t *testing.T) {
+	propagator, err := NewPropagator("none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{}
+	ctx := trace.ContextWithSpanContext(context.Background(), testSpanContext())
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+	if len(header) != 0 {
+		t.Errorf("injected headers %v, want none", header)
+	}
+}
+
+func TestNewPropagatorErrors(t *testing.T) {
+	for _, value := range []string{"bogus", "tracecontext,ottrace", "none,b3"} {
+		if _, err := NewPropagator(value); err == nil {
+			t.Errorf("NewPropagator(%q) succeeded, want an error", value)
+		}
+	}
+}
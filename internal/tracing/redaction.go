@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+
+	"opentelemetry-api/internal/redact"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// redactingProcessor redacts span attributes, event attributes and status descriptions before
+// handing ended spans to the next processor. Attributes can be set at any point of a span's
+// life, so redaction happens once the span has ended.
+type redactingProcessor struct {
+	next     trace.SpanProcessor
+	redactor *redact.Redactor
+}
+
+var _ trace.SpanProcessor = (*redactingProcessor)(nil)
+
+// OnStart implements trace.SpanProcessor.
+func (p *redactingProcessor) OnStart(parent context.Context, s trace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd implements trace.SpanProcessor.
+func (p *redactingProcessor) OnEnd(s trace.ReadOnlySpan) {
+	p.next.OnEnd(redactedSpan{ReadOnlySpan: s, redactor: p.redactor})
+}
+
+// ForceFlush implements trace.SpanProcessor.
+func (p *redactingProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+// Shutdown implements trace.SpanProcessor.
+func (p *redactingProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+// redactedSpan is a ReadOnlySpan whose attributes, events and status are redacted.
+type redactedSpan struct {
+	trace.ReadOnlySpan
+	redactor *redact.Redactor
+}
+
+// Attributes implements trace.ReadOnlySpan.
+func (s redactedSpan) Attributes() []attribute.KeyValue {
+	return s.redactor.Attributes(s.ReadOnlySpan.Attributes())
+}
+
+// Events implements trace.ReadOnlySpan.
+func (s redactedSpan) Events() []trace.Event {
+	events := s.ReadOnlySpan.Events()
+	if len(events) == 0 {
+		return events
+	}
+	redacted := make([]trace.Event, len(events))
+	for i, event := range events {
+		event.Attributes = s.redactor.Attributes(event.Attributes)
+		redacted[i] = event
+	}
+	return redacted
+}
+
+// Status implements trace.ReadOnlySpan.
+func (s redactedSpan) Status() trace.Status {
+	status := s.ReadOnlySpan.Status()
+	status.Description = s.redactor.String(status.Description)
+	return status
+}
@@ -0,0 +1,202 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// NewSampler builds the head sampler described by the standard OTEL_TRACES_SAMPLER
+// and OTEL_TRACES_SAMPLER_ARG values.
+//
+// Supported sampler names:
+//   - always_on, always_off
+//   - traceidratio: sample arg (0..1) of all traces
+//   - parentbased_always_on (default when empty), parentbased_always_off, parentbased_traceidratio
+//   - route, parentbased_route: per-route ratios loaded from rulesFile, arg is the ratio for unmatched routes
+//
+// An empty arg means a ratio of 1.0.
+//
+// Example usage:
+//
+//	sampler, err := NewSampler("parentbased_route", "0.5", "/etc/my-app/sampling.json")
+//	if err != nil {
+//	    log.Fatalf("invalid sampler configuration: %v", err)
+//	}
+//	tp, err := InitTracer("localhost:4317", "my-app", WithSampler(sampler))
+func NewSampler(name, arg, rulesFile string) (trace.Sampler, error) {
+	ratio := 1.0
+	if arg = strings.TrimSpace(arg); arg != "" {
+		var err error
+		if ratio, err = parseRatio(arg); err != nil {
+			return nil, fmt.Errorf("invalid sampler argument: %w", err)
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "always_on":
+		return trace.AlwaysSample(), nil
+	case "always_off":
+		return trace.NeverSample(), nil
+	case "traceidratio":
+		return trace.TraceIDRatioBased(ratio), nil
+	case "", "parentbased_always_on":
+		return trace.ParentBased(trace.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return trace.ParentBased(trace.NeverSample()), nil
+	case "parentbased_traceidratio":
+		return trace.ParentBased(trace.TraceIDRatioBased(ratio)), nil
+	case "route", "parentbased_route":
+		if rulesFile == "" {
+			return nil, fmt.Errorf("sampler %q requires a rules file", name)
+		}
+		rules, err := LoadRouteRules(rulesFile)
+		if err != nil {
+			return nil, err
+		}
+		sampler, err := NewRouteSampler(rules, trace.TraceIDRatioBased(ratio))
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(strings.ToLower(name), "parentbased_") {
+			return trace.ParentBased(sampler), nil
+		}
+		return sampler, nil
+	default:
+		return nil, fmt.Errorf("unsupported trace sampler %q", name)
+	}
+}
+
+// RouteRule assigns a sampling ratio to a chi route pattern (e.g. "/hello/{id}").
+// An empty Method matches every HTTP method.
+type RouteRule struct {
+	Route  string  `json:"route"`
+	Method string  `json:"method,omitempty"`
+	Ratio  float64 `json:"ratio"`
+}
+
+// routeRulesFile is the on-disk layout of a sampling rules file:
+//
+//	{"rules": [{"route": "/healthz", "ratio": 0}, {"route": "/hello/{id}", "ratio": 0.1}]}
+type routeRulesFile struct {
+	Rules []RouteRule `json:"rules"`
+}
+
+// LoadRouteRules reads a JSON sampling rules file.
+func LoadRouteRules(path string) ([]RouteRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sampling rules file: %w", err)
+	}
+	var f routeRulesFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse sampling rules file %s: %w", path, err)
+	}
+	return f.Rules, nil
+}
+
+// routeSampler applies a per-route ratio to root spans of incoming HTTP requests.
+type routeSampler struct {
+	rules       map[string]trace.Sampler // Keyed by "METHOD route" or " route" for any method
+	fallback    trace.Sampler            // Used when no rule matches the request
+	description string
+}
+
+// NewRouteSampler returns a Sampler that picks a ratio based on the chi route pattern
+// of the request being traced, delegating to fallback for routes without a rule.
+//
+// The route is resolved from the chi routing context found in the parent context,
+// which is available when the span is started by middleware registered on the chi
+// router (e.g. otelhttp.NewMiddleware). Since chi has not routed the request yet at
+// that point, the pattern is looked up with the router's Find method using the HTTP
+// method and path attributes of the span. When no chi context is present the raw
+// request path is matched instead, so literal routes such as "/healthz" still work.
+func NewRouteSampler(rules []RouteRule, fallback trace.Sampler) (trace.Sampler, error) {
+	s := &routeSampler{
+		rules:    make(map[string]trace.Sampler, len(rules)),
+		fallback: fallback,
+	}
+	descs := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Route == "" {
+			return nil, fmt.Errorf("sampling rule is missing a route")
+		}
+		if rule.Ratio < 0 || rule.Ratio > 1 {
+			return nil, fmt.Errorf("sampling ratio %v for route %q is outside [0, 1]", rule.Ratio, rule.Route)
+		}
+		method := strings.ToUpper(rule.Method)
+		s.rules[method+" "+rule.Route] = trace.TraceIDRatioBased(rule.Ratio)
+		descs = append(descs, fmt.Sprintf("%s %s=%g", method, rule.Route, rule.Ratio))
+	}
+	s.description = fmt.Sprintf("RouteSampler{%s;fallback:%s}", strings.Join(descs, ","), fallback.Description())
+	return s, nil
+}
+
+// ShouldSample implements trace.Sampler.
+func (s *routeSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	method, path := requestFromAttributes(p.Attributes)
+	route := path
+
+	// Resolve the normalized route pattern through the chi router handling the request
+	if rctx := chi.RouteContext(p.ParentContext); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			route = pattern
+		} else if rctx.Routes != nil && method != "" && path != "" {
+			if pattern := rctx.Routes.Find(chi.NewRouteContext(), method, path); pattern != "" {
+				route = pattern
+			}
+		}
+	}
+
+	if route != "" {
+		if sampler, ok := s.rules[method+" "+route]; ok {
+			return sampler.ShouldSample(p)
+		}
+		if sampler, ok := s.rules[" "+route]; ok {
+			return sampler.ShouldSample(p)
+		}
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+// Description implements trace.Sampler.
+func (s *routeSampler) Description() string {
+	return s.description
+}
+
+// requestFromAttributes extracts the HTTP method and path from span start attributes,
+// accepting both the stable (http.request.method, url.path) and the legacy
+// (http.method, http.target) semantic convention keys.
+func requestFromAttributes(attrs []attribute.KeyValue) (method, path string) {
+	for _, kv := range attrs {
+		switch kv.Key {
+		case "http.request.method", "http.method":
+			method = kv.Value.AsString()
+		case "url.path", "http.target":
+			path = kv.Value.AsString()
+		}
+	}
+	// http.target may carry a query string
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	return method, path
+}
+
+// parseRatio parses a sampling ratio and checks that it lies within [0, 1].
+func parseRatio(s string) (float64, error) {
+	ratio, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if ratio < 0 || ratio > 1 {
+		return 0, fmt.Errorf("ratio %v is outside [0, 1]", ratio)
+	}
+	return ratio, nil
+}
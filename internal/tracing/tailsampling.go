@@ -0,0 +1,365 @@
+package tracing
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// TailSamplingConfig configures the TailSamplingProcessor.
+type TailSamplingConfig struct {
+	DecisionWait     time.Duration        // How long a trace is buffered waiting for its root span (default 10s)
+	LatencyThreshold time.Duration        // Keep traces whose root span took at least this long (0 disables the check)
+	KeepRatio        float64              // Fraction of traces kept even if they are neither errored nor slow
+	MaxTraces        int                  // Maximum number of traces buffered at once, and of decisions cached (default 10000)
+	MaxSpansPerTrace int                  // Maximum number of spans buffered per trace (default 1000)
+	MeterProvider    metric.MeterProvider // Used for the processor's own metrics (defaults to the global provider)
+}
+
+// Tail sampling decision reasons, reported as the "reason" attribute on the processor metrics.
+const (
+	reasonError    = "error"
+	reasonLatency  = "latency"
+	reasonRatio    = "ratio"
+	reasonNone     = "none"
+	reasonTimeout  = "timeout"
+	reasonCapacity = "capacity"
+	reasonFlush    = "flush"
+	reasonShutdown = "shutdown"
+)
+
+// minSweepInterval bounds how often buffered traces are checked for timeouts, whatever the
+// DecisionWait.
+const minSweepInterval = time.Millisecond
+
+// pendingTrace holds the spans of a trace whose sampling decision has not been made yet.
+type pendingTrace struct {
+	traceID   oteltrace.TraceID
+	elem      *list.Element // Position in TailSamplingProcessor.order
+	spans     []trace.ReadOnlySpan
+	firstSeen time.Time
+	errored   bool          // Any span ended with codes.Error
+	latency   time.Duration // Root span duration once it ended, otherwise the longest span seen so far
+}
+
+// decision is the cached verdict for a trace, applied to spans that end after it was taken.
+type decision struct {
+	traceID oteltrace.TraceID
+	keep    bool
+	expires time.Time
+}
+
+// TailSamplingProcessor is a trace.SpanProcessor that buffers the spans of each trace and only
+// forwards the trace to the next processor (typically the batcher) once it is known to be
+// interesting: a span ended with an error status, the local root span exceeded the latency
+// threshold, or the probabilistic keep fired.
+//
+// The decision is taken when the local root span ends (a span without a parent or with a remote
+// parent). Traces whose root does not end within DecisionWait, or that are evicted because
+// MaxTraces is reached, are decided with the spans received so far. Spans ending after their
+// trace has been decided follow the cached decision for another DecisionWait. At most MaxTraces
+// decisions are cached; the oldest are forgotten first.
+//
+// The head sampler should record every trace (e.g. trace.AlwaysSample()) so that errored and
+// slow traces are not dropped before they reach this processor.
+type TailSamplingProcessor struct {
+	next trace.SpanProcessor
+	cfg  TailSamplingConfig
+
+	mu            sync.Mutex
+	traces        map[oteltrace.TraceID]*pendingTrace
+	order         *list.List // *pendingTrace in insertion order, oldest first, used for eviction
+	decisions     map[oteltrace.TraceID]*list.Element
+	decisionOrder *list.List // *decision in the order they were taken, oldest (first to expire) first
+
+	decidedTraces metric.Int64Counter // Traces decided, by decision and reason
+	droppedSpans  metric.Int64Counter // Spans discarded because a trace exceeded MaxSpansPerTrace
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+var _ trace.SpanProcessor = (*TailSamplingProcessor)(nil)
+
+// NewTailSamplingProcessor creates a TailSamplingProcessor forwarding kept traces to next.
+//
+// Example usage:
+//
+//	bsp := trace.NewBatchSpanProcessor(exporter)
+//	tsp := NewTailSamplingProcessor(bsp, TailSamplingConfig{LatencyThreshold: time.Second, KeepRatio: 0.05})
+//	tp := trace.NewTracerProvider(trace.WithSpanProcessor(tsp))
+func NewTailSamplingProcessor(next trace.SpanProcessor, cfg TailSamplingConfig) *TailSamplingProcessor {
+	if cfg.DecisionWait <= 0 {
+		cfg.DecisionWait = 10 * time.Second
+	}
+	if cfg.MaxTraces <= 0 {
+		cfg.MaxTraces = 10000
+	}
+	if cfg.MaxSpansPerTrace <= 0 {
+		cfg.MaxSpansPerTrace = 1000
+	}
+	if cfg.MeterProvider == nil {
+		cfg.MeterProvider = otel.GetMeterProvider()
+	}
+
+	p := &TailSamplingProcessor{
+		next:          next,
+		cfg:           cfg,
+		traces:        make(map[oteltrace.TraceID]*pendingTrace),
+		order:         list.New(),
+		decisions:     make(map[oteltrace.TraceID]*list.Element),
+		decisionOrder: list.New(),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	p.initMetrics()
+
+	go p.run()
+	return p
+}
+
+// initMetrics registers the processor's self-observability instruments. Instrument creation
+// errors are reported through the global error handler and leave no-op instruments in place.
+func (p *TailSamplingProcessor) initMetrics() {
+	meter := p.cfg.MeterProvider.Meter("opentelemetry-api/internal/tracing")
+
+	var err error
+	p.decidedTraces, err = meter.Int64Counter(
+		"tail_sampling_traces_total",
+		metric.WithDescription("Traces decided by the tail sampling processor, by decision and reason"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	p.droppedSpans, err = meter.Int64Counter(
+		"tail_sampling_dropped_spans_total",
+		metric.WithDescription("Spans discarded because their trace exceeded the per-trace span limit"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	_, err = meter.Int64ObservableGauge(
+		"tail_sampling_buffered_traces",
+		metric.WithDescription("Traces currently buffered awaiting a tail sampling decision"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			p.mu.Lock()
+			n := len(p.traces)
+			p.mu.Unlock()
+			o.Observe(int64(n))
+			return nil
+		}),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+}
+
+// OnStart implements trace.SpanProcessor.
+func (p *TailSamplingProcessor) OnStart(parent context.Context, s trace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd implements trace.SpanProcessor.
+func (p *TailSamplingProcessor) OnEnd(s trace.ReadOnlySpan) {
+	traceID := s.SpanContext().TraceID()
+	now := time.Now()
+
+	p.mu.Lock()
+	// The trace was already decided: follow the cached decision
+	if elem, ok := p.decisions[traceID]; ok {
+		keep := elem.Value.(*decision).keep
+		p.mu.Unlock()
+		if keep {
+			p.next.OnEnd(s)
+		}
+		return
+	}
+
+	var forward []trace.ReadOnlySpan
+	pt, ok := p.traces[traceID]
+	if !ok {
+		// Make room for the new trace by deciding the oldest one early
+		if len(p.traces) >= p.cfg.MaxTraces {
+			forward = p.decideLocked(p.order.Front().Value.(*pendingTrace).traceID, reasonCapacity, now)
+		}
+		pt = &pendingTrace{traceID: traceID, firstSeen: now}
+		pt.elem = p.order.PushBack(pt)
+		p.traces[traceID] = pt
+	}
+
+	if s.Status().Code == codes.Error {
+		pt.errored = true
+	}
+	duration := s.EndTime().Sub(s.StartTime())
+	if duration > pt.latency {
+		pt.latency = duration
+	}
+	if len(pt.spans) < p.cfg.MaxSpansPerTrace {
+		pt.spans = append(pt.spans, s)
+	} else {
+		p.droppedSpans.Add(context.Background(), 1)
+	}
+
+	// The local root ended: the trace is complete from this process' point of view
+	if parent := s.Parent(); !parent.IsValid() || parent.IsRemote() {
+		pt.latency = duration
+		forward = append(forward, p.decideLocked(traceID, "", now)...)
+	}
+	p.mu.Unlock()
+
+	for _, span := range forward {
+		p.next.OnEnd(span)
+	}
+}
+
+// decideLocked takes the sampling decision for a buffered trace, removes it from the buffer and
+// returns the spans to forward. A non-empty forced reason records why the decision was taken
+// before the root span ended; it is only reported when no rule kept the trace, so that e.g. an
+// errored trace evicted at capacity still counts as kept for its error. p.mu must be held.
+func (p *TailSamplingProcessor) decideLocked(traceID oteltrace.TraceID, forced string, now time.Time) []trace.ReadOnlySpan {
+	pt, ok := p.traces[traceID]
+	if !ok {
+		return nil
+	}
+	delete(p.traces, traceID)
+	p.order.Remove(pt.elem)
+
+	keep, reason := true, reasonError
+	switch {
+	case pt.errored:
+	case p.cfg.LatencyThreshold > 0 && pt.latency >= p.cfg.LatencyThreshold:
+		reason = reasonLatency
+	case keepByRatio(traceID, p.cfg.KeepRatio):
+		reason = reasonRatio
+	default:
+		keep, reason = false, reasonNone
+	}
+	if !keep && forced != "" {
+		reason = forced
+	}
+
+	// Cache the decision, forgetting the oldest one when the cache is full
+	if p.decisionOrder.Len() >= p.cfg.MaxTraces {
+		oldest := p.decisionOrder.Remove(p.decisionOrder.Front()).(*decision)
+		delete(p.decisions, oldest.traceID)
+	}
+	p.decisions[traceID] = p.decisionOrder.PushBack(&decision{traceID: traceID, keep: keep, expires: now.Add(p.cfg.DecisionWait)})
+	p.decidedTraces.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.Bool("kept", keep),
+		attribute.String("reason", reason),
+	))
+
+	if !keep {
+		return nil
+	}
+	return pt.spans
+}
+
+// run periodically decides traces that outlived DecisionWait and expires cached decisions.
+func (p *TailSamplingProcessor) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(max(p.cfg.DecisionWait/2, minSweepInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			for _, span := range p.sweep(now) {
+				p.next.OnEnd(span)
+			}
+		}
+	}
+}
+
+// sweep decides timed out traces and drops expired decisions, returning the spans to forward.
+func (p *TailSamplingProcessor) sweep(now time.Time) []trace.ReadOnlySpan {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var forward []trace.ReadOnlySpan
+	for front := p.order.Front(); front != nil; front = p.order.Front() {
+		oldest := front.Value.(*pendingTrace)
+		if now.Sub(oldest.firstSeen) < p.cfg.DecisionWait {
+			break
+		}
+		forward = append(forward, p.decideLocked(oldest.traceID, reasonTimeout, now)...)
+	}
+	// Decisions expire in the order they were taken
+	for front := p.decisionOrder.Front(); front != nil; front = p.decisionOrder.Front() {
+		d := front.Value.(*decision)
+		if !now.After(d.expires) {
+			break
+		}
+		p.decisionOrder.Remove(front)
+		delete(p.decisions, d.traceID)
+	}
+	return forward
+}
+
+// flush decides every buffered trace immediately and forwards the kept spans.
+func (p *TailSamplingProcessor) flush(reason string) {
+	now := time.Now()
+
+	p.mu.Lock()
+	var forward []trace.ReadOnlySpan
+	for front := p.order.Front(); front != nil; front = p.order.Front() {
+		forward = append(forward, p.decideLocked(front.Value.(*pendingTrace).traceID, reason, now)...)
+	}
+	p.mu.Unlock()
+
+	for _, span := range forward {
+		p.next.OnEnd(span)
+	}
+}
+
+// ForceFlush implements trace.SpanProcessor. Buffered traces are decided immediately.
+func (p *TailSamplingProcessor) ForceFlush(ctx context.Context) error {
+	p.flush(reasonFlush)
+	return p.next.ForceFlush(ctx)
+}
+
+// Shutdown implements trace.SpanProcessor. Buffered traces are decided before the next
+// processor is shut down, which also happens when ctx is done before the background sweep
+// stopped; ctx.Err() is then returned along with any error of the next processor.
+func (p *TailSamplingProcessor) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	var err error
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		// The sweep may still be forwarding spans; buffered traces are safe to decide concurrently
+		err = ctx.Err()
+	}
+	p.flush(reasonShutdown)
+	return errors.Join(err, p.next.Shutdown(ctx))
+}
+
+// keepByRatio makes a deterministic probabilistic decision from the trace ID, using the same
+// scheme as trace.TraceIDRatioBased so that every service keeps the same traces.
+func keepByRatio(traceID oteltrace.TraceID, ratio float64) bool {
+	if ratio <= 0 {
+		return false
+	}
+	if ratio >= 1 {
+		return true
+	}
+	bound := uint64(ratio * (1 << 63))
+	x := binary.BigEndian.Uint64(traceID[8:16]) >> 1
+	return x < bound
+}
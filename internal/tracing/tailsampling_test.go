@@ -0,0 +1,296 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// tailSamplingPipeline is a TracerProvider whose spans go through a TailSamplingProcessor into
+// an in-memory exporter.
+type tailSamplingPipeline struct {
+	processor *TailSamplingProcessor
+	tracer    oteltrace.Tracer
+	exporter  *tracetest.InMemoryExporter
+	reader    *sdkmetric.ManualReader
+}
+
+func newTailSamplingPipeline(t *testing.T, cfg TailSamplingConfig) *tailSamplingPipeline {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	cfg.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	exporter := tracetest.NewInMemoryExporter()
+	processor := NewTailSamplingProcessor(sdktrace.NewSimpleSpanProcessor(exporter), cfg)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	return &tailSamplingPipeline{processor: processor, tracer: tp.Tracer("test"), exporter: exporter, reader: reader}
+}
+
+// exported returns the names of the exported spans.
+func (p *tailSamplingPipeline) exported() []string {
+	var names []string
+	for _, s := range p.exporter.GetSpans() {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// decisions returns the tail_sampling_traces_total counts keyed by "kept/reason".
+func (p *tailSamplingPipeline) decisions(t *testing.T) map[string]int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := p.reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "tail_sampling_traces_total" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				kept, _ := dp.Attributes.Value("kept")
+				reason, _ := dp.Attributes.Value("reason")
+				counts[kept.Emit()+"/"+reason.AsString()] += dp.Value
+			}
+		}
+	}
+	return counts
+}
+
+// startChild starts a trace with a root span and returns a child span of it, leaving the root
+// unfinished so that the trace stays buffered.
+func (p *tailSamplingPipeline) startChild(name string) oteltrace.Span {
+	ctx, _ := p.tracer.Start(context.Background(), name+"-root")
+	_, child := p.tracer.Start(ctx, name)
+	return child
+}
+
+func TestTailSamplingKeepsErroredTraces(t *testing.T) {
+	p := newTailSamplingPipeline(t, TailSamplingConfig{})
+
+	ctx, root := p.tracer.Start(context.Background(), "root")
+	_, child := p.tracer.Start(ctx, "child")
+	child.SetStatus(codes.Error, "failed")
+	child.End()
+	if n := len(p.exported()); n != 0 {
+		t.Fatalf("%d spans exported before the root ended, want 0", n)
+	}
+	root.End()
+
+	if got := p.exported(); len(got) != 2 {
+		t.Errorf("exported %v, want child and root", got)
+	}
+	if got := p.decisions(t)["true/error"]; got != 1 {
+		t.Errorf("kept/error decisions = %d, want 1", got)
+	}
+}
+
+func TestTailSamplingDropsUnremarkableTraces(t *testing.T) {
+	p := newTailSamplingPipeline(t, TailSamplingConfig{LatencyThreshold: time.Hour})
+
+	_, root := p.tracer.Start(context.Background(), "root")
+	root.End()
+
+	if got := p.exported(); len(got) != 0 {
+		t.Errorf("exported %v, want nothing", got)
+	}
+	if got := p.decisions(t)["false/none"]; got != 1 {
+		t.Errorf("dropped/none decisions = %d, want 1", got)
+	}
+}
+
+func TestTailSamplingKeepsSlowTraces(t *testing.T) {
+	p := newTailSamplingPipeline(t, TailSamplingConfig{LatencyThreshold: 100 * time.Millisecond})
+
+	start := time.Now()
+	_, slow := p.tracer.Start(context.Background(), "slow", oteltrace.WithTimestamp(start))
+	slow.End(oteltrace.WithTimestamp(start.Add(200 * time.Millisecond)))
+	_, fast := p.tracer.Start(context.Background(), "fast", oteltrace.WithTimestamp(start))
+	fast.End(oteltrace.WithTimestamp(start.Add(time.Millisecond)))
+
+	if got := p.exported(); len(got) != 1 || got[0] != "slow" {
+		t.Errorf("exported %v, want [slow]", got)
+	}
+	decisions := p.decisions(t)
+	if decisions["true/latency"] != 1 || decisions["false/none"] != 1 {
+		t.Errorf("decisions = %v, want one kept/latency and one dropped/none", decisions)
+	}
+}
+
+func TestTailSamplingKeepsByRatio(t *testing.T) {
+	p := newTailSamplingPipeline(t, TailSamplingConfig{KeepRatio: 1})
+
+	_, root := p.tracer.Start(context.Background(), "root")
+	root.End()
+
+	if got := p.exported(); len(got) != 1 {
+		t.Errorf("exported %v, want [root]", got)
+	}
+	if got := p.decisions(t)["true/ratio"]; got != 1 {
+		t.Errorf("kept/ratio decisions = %d, want 1", got)
+	}
+}
+
+func TestKeepByRatio(t *testing.T) {
+	low := oteltrace.TraceID{} // Lowest random part: kept by any positive ratio
+	high := oteltrace.TraceID{8: 0xff, 9: 0xff, 10: 0xff, 11: 0xff, 12: 0xff, 13: 0xff, 14: 0xff, 15: 0xff}
+
+	tests := []struct {
+		traceID oteltrace.TraceID
+		ratio   float64
+		want    bool
+	}{
+		{low, 0, false},
+		{low, 0.01, true},
+		{high, 0.5, false},
+		{high, 1, true},
+	}
+	for _, tt := range tests {
+		if got := keepByRatio(tt.traceID, tt.ratio); got != tt.want {
+			t.Errorf("keepByRatio(%s, %v) = %v, want %v", tt.traceID, tt.ratio, got, tt.want)
+		}
+	}
+}
+
+func TestTailSamplingEvictsOldestTraceAtCapacity(t *testing.T) {
+	p := newTailSamplingPipeline(t, TailSamplingConfig{MaxTraces: 1})
+
+	first := p.startChild("first")
+	first.SetStatus(codes.Error, "failed")
+	first.End()
+	if n := len(p.exported()); n != 0 {
+		t.Fatalf("%d spans exported before eviction, want 0", n)
+	}
+
+	// A second trace does not fit: the first one is decided with the spans it has so far
+	p.startChild("second").End()
+	// And the second one in turn, which no rule keeps
+	p.startChild("third").End()
+
+	if got := p.exported(); len(got) != 1 || got[0] != "first" {
+		t.Errorf("exported %v, want [first]", got)
+	}
+	// The evicted errored trace is kept for its error, only the dropped one is blamed on capacity
+	decisions := p.decisions(t)
+	if decisions["true/error"] != 1 || decisions["false/capacity"] != 1 || len(decisions) != 2 {
+		t.Errorf("decisions = %v, want one kept/error and one dropped/capacity", decisions)
+	}
+}
+
+func TestTailSamplingDecidesTimedOutTraces(t *testing.T) {
+	p := newTailSamplingPipeline(t, TailSamplingConfig{DecisionWait: 20 * time.Millisecond})
+
+	child := p.startChild("orphan")
+	child.SetStatus(codes.Error, "failed")
+	child.End()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(p.exported()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := p.exported(); len(got) != 1 || got[0] != "orphan" {
+		t.Errorf("exported %v, want [orphan]", got)
+	}
+	if got := p.decisions(t)["true/error"]; got != 1 {
+		t.Errorf("kept/error decisions = %d, want 1", got)
+	}
+}
+
+func TestTailSamplingDropsTimedOutTraces(t *testing.T) {
+	p := newTailSamplingPipeline(t, TailSamplingConfig{DecisionWait: time.Nanosecond})
+
+	p.startChild("orphan").End()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(p.decisions(t)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := p.decisions(t)["false/timeout"]; got != 1 {
+		t.Errorf("dropped/timeout decisions = %d, want 1", got)
+	}
+	if got := p.exported(); len(got) != 0 {
+		t.Errorf("exported %v, want nothing", got)
+	}
+}
+
+// shutdownRecorder records whether the processor it wraps was shut down.
+type shutdownRecorder struct {
+	sdktrace.SpanProcessor
+	shutdown bool
+}
+
+func (r *shutdownRecorder) Shutdown(ctx context.Context) error {
+	r.shutdown = true
+	return r.SpanProcessor.Shutdown(ctx)
+}
+
+func TestTailSamplingShutdownAfterDeadline(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	next := &shutdownRecorder{SpanProcessor: sdktrace.NewSimpleSpanProcessor(exporter)}
+	processor := NewTailSamplingProcessor(next, TailSamplingConfig{MeterProvider: sdkmetric.NewMeterProvider()})
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor))
+
+	// The root never ends, so the trace is still buffered at shutdown
+	rootCtx, _ := tp.Tracer("test").Start(context.Background(), "root")
+	_, child := tp.Tracer("test").Start(rootCtx, "child")
+	child.SetStatus(codes.Error, "failed")
+	child.End()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = processor.Shutdown(ctx)
+
+	if !next.shutdown {
+		t.Error("next processor was not shut down")
+	}
+	if got := exporter.GetSpans(); len(got) != 1 || got[0].Name != "child" {
+		t.Errorf("exported %v, want the buffered child span", got)
+	}
+}
+
+func TestTailSamplingFollowsCachedDecision(t *testing.T) {
+	p := newTailSamplingPipeline(t, TailSamplingConfig{})
+
+	ctx, root := p.tracer.Start(context.Background(), "root")
+	_, late := p.tracer.Start(ctx, "late")
+	root.SetStatus(codes.Error, "failed")
+	root.End()
+	// The trace was kept when its root ended: spans ending afterwards are exported directly
+	late.End()
+
+	if got := p.exported(); len(got) != 2 || got[1] != "late" {
+		t.Errorf("exported %v, want [root late]", got)
+	}
+}
+
+func TestTailSamplingBoundsDecisionCache(t *testing.T) {
+	p := newTailSamplingPipeline(t, TailSamplingConfig{MaxTraces: 2})
+
+	for i := 0; i < 10; i++ {
+		_, root := p.tracer.Start(context.Background(), "root", oteltrace.WithAttributes(attribute.Int("i", i)))
+		root.End()
+	}
+
+	p.processor.mu.Lock()
+	defer p.processor.mu.Unlock()
+	if n := len(p.processor.decisions); n != 2 {
+		t.Errorf("%d decisions cached, want MaxTraces (2)", n)
+	}
+	if n := p.processor.decisionOrder.Len(); n != 2 {
+		t.Errorf("decision order holds %d entries, want 2", n)
+	}
+	if len(p.processor.traces) != 0 || p.processor.order.Len() != 0 {
+		t.Error("decided traces are still buffered")
+	}
+}
@@ -4,22 +4,22 @@ import (
 	"context"
 	"fmt"
 
+	"opentelemetry-api/internal/resource"
+
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
 // InitTracer initializes and configures an OpenTelemetry TracerProvider for tracing.
-// It sets up an OTLP trace exporter, a resource with service attributes, and a tracer provider
+// It sets up a trace exporter, a resource with service attributes, and a tracer provider
 // with batching and sampling configurations. Additionally, it configures the global tracer provider
 // and text map propagator for context propagation.
 //
 // Parameters:
 //   - endpoint: The OTLP endpoint to which trace data will be exported.
 //   - serviceName: The name of the service (e.g., "my-app").
+//   - opts: Optional settings such as WithExporter, WithSampler and WithTailSampling. By default every span is
+//     sampled and exported over OTLP gRPC.
 //
 // Returns:
 //   - *trace.TracerProvider: The initialized TracerProvider instance.
@@ -27,35 +27,56 @@ import (
 //
 // Example usage:
 //
-//	tp, err := InitTracer("localhost:4317", "my-app")
+//	tp, err := InitTracer("localhost:4317", "my-app", WithExporter(ExporterOTLPHTTP))
 //	if err != nil {
 //	    log.Fatalf("failed to initialize tracer: %v", err)
 //	}
 //	defer tp.Shutdown(context.Background())
-func InitTracer(endpoint, serviceName string) (*trace.TracerProvider, error) {
+func InitTracer(endpoint, serviceName string, opts ...Option) (*trace.TracerProvider, error) {
 	ctx := context.Background()
+	cfg := newConfig(opts...)
 
-	// Create OTLP trace exporter
-	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+	// Create the trace exporter selected by the configuration
+	exporter, err := newExporter(ctx, cfg, endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", cfg.exporter, err)
 	}
 
-	// Create resource with the provided service name
-	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceNameKey.String(serviceName)))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
+	// Use the shared resource, or detect one for the provided service name
+	res := cfg.resource
+	if res == nil {
+		res, err = resource.New(ctx, resource.Config{ServiceName: serviceName})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Create tracer provider
-	tp := trace.NewTracerProvider(
-		trace.WithBatcher(exporter),
-		trace.WithSampler(trace.AlwaysSample()),
-		trace.WithResource(res))
+	tpOpts := []trace.TracerProviderOption{
+		trace.WithSampler(cfg.sampler),
+		trace.WithResource(res),
+	}
+	// ExporterNone yields no exporter: spans are still created for context propagation but never exported
+	if exporter != nil {
+		var processor trace.SpanProcessor = trace.NewBatchSpanProcessor(exporter)
+		if cfg.tail != nil {
+			// Buffer whole traces and only hand the interesting ones to the batcher
+			processor = NewTailSamplingProcessor(processor, *cfg.tail)
+		}
+		if cfg.redactor != nil {
+			// Redact ended spans before they are buffered or exported
+			processor = &redactingProcessor{next: processor, redactor: cfg.redactor}
+		}
+		tpOpts = append(tpOpts, trace.WithSpanProcessor(processor))
+	}
+	for _, processor := range cfg.processors {
+		tpOpts = append(tpOpts, trace.WithSpanProcessor(processor))
+	}
+	tp := trace.NewTracerProvider(tpOpts...)
 
 	// Set the global tracer provider and propagator
 	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	otel.SetTextMapPropagator(cfg.propagator)
 
 	return tp, nil
 }